@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"pptx2mp4/backend/internal/api"
 	"pptx2mp4/backend/internal/api/handlers"
 	"pptx2mp4/backend/internal/config"
@@ -29,10 +31,25 @@ func main() {
 		"port":        cfg.Port,
 		"storagePath": cfg.StoragePath,
 		"logLevel":    cfg.LogLevel,
+		"ttsEngine":   cfg.TTSEngine,
+		"parallelism": cfg.EncoderParallelism,
+		"officePool":  cfg.OfficePoolSize,
+		"jobTimeout":  cfg.JobTimeout,
+		"jobWorkers":  cfg.JobWorkers,
 	}).Info("konfiguration geladen")
 
-	jobRepo := repository.NewInMemoryJobRepository()
-	logger.Info("job-repository initialisiert")
+	var jobRepo repository.JobRepository
+	switch cfg.JobStore {
+	case "memory":
+		jobRepo = repository.NewInMemoryJobRepository()
+	default:
+		boltJobRepo, err := repository.NewBoltJobRepository(filepath.Join(cfg.StoragePath, "jobs.db"), logger)
+		if err != nil {
+			logger.WithError(err).Fatal("job-repository konnte nicht geöffnet werden")
+		}
+		jobRepo = boltJobRepo
+	}
+	logger.WithField("jobStore", cfg.JobStore).Info("job-repository initialisiert")
 
 	fileRepo := repository.NewFileSystemRepository(cfg.StoragePath)
 	if err := fileRepo.ValidateStoragePath(); err != nil {
@@ -40,16 +57,44 @@ func main() {
 	}
 	logger.Info("file-repository initialisiert")
 
-	pptxConverter := converter.NewLibreOfficeConverter(logger)
-	pdfConverter := converter.NewPopplerConverter(logger)
-	videoEncoder := converter.NewFFmpegEncoder(logger)
+	var pptxConverter converter.PPTXConverter = converter.NewLibreOfficeConverter(logger)
+	if cfg.OfficePoolSize > 0 {
+		officePool := converter.NewLibreOfficePool(converter.LibreOfficePoolOptions{
+			Size:              cfg.OfficePoolSize,
+			BasePort:          cfg.OfficeBasePort,
+			MaxConversions:    cfg.OfficeMaxConversions,
+			ConversionTimeout: cfg.OfficeConversionTimeout,
+			ProfileDir:        filepath.Join(cfg.StoragePath, "office"),
+		}, logger)
+		if err := officePool.Start(); err != nil {
+			logger.WithError(err).Fatal("LibreOffice-Pool konnte nicht gestartet werden")
+		}
+		pptxConverter = officePool
+	}
+	pptxParser := converter.NewOOXMLParser(logger)
+	pdfConverter := converter.NewPopplerConverter(cfg.RasterParallelism, logger)
+	videoEncoder := converter.NewFFmpegEncoder(cfg.EncoderParallelism, logger)
+	mediaProber := converter.NewFFprobeProber(logger)
+	synthesizer, err := converter.NewNarrationSynthesizer(cfg.TTSEngine, cfg.PiperModelDir, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("ungültige TTS-Konfiguration")
+	}
 	logger.Info("converter initialisiert")
 
 	conversionService := service.NewConversionService(
 		fileRepo,
 		pptxConverter,
+		pptxParser,
 		pdfConverter,
 		videoEncoder,
+		mediaProber,
+		synthesizer,
+		service.StageTimeouts{
+			PDF:       cfg.PDFTimeout,
+			Images:    cfg.RasterTimeout,
+			Narration: cfg.NarrationTimeout,
+			Encode:    cfg.EncodeTimeout,
+		},
 		logger,
 	)
 
@@ -59,12 +104,34 @@ func main() {
 	logger.Info("externe Abhängigkeiten validiert")
 
 	fileService := service.NewFileService(fileRepo, logger)
-	jobService := service.NewJobService(jobRepo, conversionService, logger)
+	jobService := service.NewJobService(jobRepo, fileRepo, conversionService, service.JobServiceOptions{
+		Workers:        cfg.JobWorkers,
+		MaxQueueLength: cfg.MaxQueueLength,
+		JobTimeout:     cfg.JobTimeout,
+	}, logger)
+	if err := jobService.RecoverJobs(); err != nil {
+		logger.WithError(err).Fatal("gespeicherte Jobs konnten nicht wiederhergestellt werden")
+	}
+	jobService.Start(context.Background())
+	janitor := service.NewJanitor(jobService, fileRepo, service.JanitorOptions{
+		Interval:         cfg.CleanupInterval,
+		FailedJobTTL:     cfg.FailedJobTTL,
+		CompletedJobTTL:  cfg.CompletedJobTTL,
+		DownloadedJobTTL: cfg.DownloadedJobTTL,
+		OrphanTTL:        cfg.OrphanTTL,
+	}, logger)
+	janitor.Start(context.Background())
 	logger.Info("services initialisiert")
 
-	uploadHandler := handlers.NewUploadHandler(fileService, jobService, logger)
+	uploadHandler := handlers.NewUploadHandler(fileService, jobService, cfg.TTSEngine, logger)
 	statusHandler := handlers.NewStatusHandler(jobService, logger)
 	downloadHandler := handlers.NewDownloadHandler(jobService, fileService, logger)
+	subtitleHandler := handlers.NewSubtitleHandler(jobService, logger)
+	chapterHandler := handlers.NewChapterHandler(jobService, logger)
+	cancelHandler := handlers.NewCancelHandler(jobService, logger)
+	retryHandler := handlers.NewRetryHandler(jobService, logger)
+	eventsHandler := handlers.NewEventsHandler(jobService, logger)
+	capabilitiesHandler := handlers.NewCapabilitiesHandler(cfg.TTSEngine, logger)
 	healthHandler := handlers.NewHealthHandler(logger)
 	logger.Info("handlers initialisiert")
 
@@ -72,6 +139,12 @@ func main() {
 		uploadHandler,
 		statusHandler,
 		downloadHandler,
+		subtitleHandler,
+		chapterHandler,
+		cancelHandler,
+		retryHandler,
+		eventsHandler,
+		capabilitiesHandler,
 		healthHandler,
 		logger,
 		cfg.AllowedOrigins,
@@ -131,5 +204,33 @@ func validateConfig(cfg *config.Config) error {
 		return fmt.Errorf("max-file-size muss größer als 0 sein")
 	}
 
+	if cfg.EncoderParallelism < 1 {
+		return fmt.Errorf("encoder-parallelism muss mindestens 1 sein")
+	}
+
+	if cfg.RasterParallelism < 1 {
+		return fmt.Errorf("raster-parallelism muss mindestens 1 sein")
+	}
+
+	if cfg.JobStore != "bolt" && cfg.JobStore != "memory" {
+		return fmt.Errorf("job-store muss bolt oder memory sein")
+	}
+
+	if cfg.JobWorkers < 1 {
+		return fmt.Errorf("job-workers muss mindestens 1 sein")
+	}
+
+	if cfg.MaxQueueLength < 1 {
+		return fmt.Errorf("max-queue-length muss mindestens 1 sein")
+	}
+
+	if cfg.OfficePoolSize < 0 {
+		return fmt.Errorf("office-pool-size darf nicht negativ sein")
+	}
+
+	if cfg.OfficePoolSize > 0 && cfg.OfficeConversionTimeout <= 0 {
+		return fmt.Errorf("office-conversion-timeout muss größer als 0 sein")
+	}
+
 	return nil
 }
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pptx2mp4/backend/internal/domain"
+	"pptx2mp4/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type CancelHandler struct {
+	jobService service.JobService
+	logger     *logrus.Logger
+}
+
+func NewCancelHandler(jobService service.JobService, logger *logrus.Logger) *CancelHandler {
+	return &CancelHandler{
+		jobService: jobService,
+		logger:     logger,
+	}
+}
+
+// HandleCancel bricht einen wartenden oder laufenden Job ab und löscht
+// seine Dateien. Abgeschlossene Jobs können nicht abgebrochen werden.
+func (h *CancelHandler) HandleCancel(c *gin.Context) {
+	jobID := c.Param("jobId")
+
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ungültige Request",
+			"message": "Job-ID fehlt",
+		})
+		return
+	}
+
+	job, err := h.jobService.CancelJob(jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Job nicht gefunden",
+				"message": "Der angeforderte Job existiert nicht",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidJobStatus) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Job nicht abbrechbar",
+				"message": "Der Job ist bereits beendet",
+				"status":  job.Status,
+			})
+			return
+		}
+
+		h.logger.WithError(err).Error("fehler beim Abbrechen des Jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Serverfehler",
+			"message": "Job konnte nicht abgebrochen werden",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobId":  job.ID,
+		"status": job.Status,
+	})
+}
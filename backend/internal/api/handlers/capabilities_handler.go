@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"pptx2mp4/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type CapabilitiesHandler struct {
+	ttsEngine string
+	logger    *logrus.Logger
+}
+
+func NewCapabilitiesHandler(ttsEngine string, logger *logrus.Logger) *CapabilitiesHandler {
+	return &CapabilitiesHandler{
+		ttsEngine: ttsEngine,
+		logger:    logger,
+	}
+}
+
+func (h *CapabilitiesHandler) HandleCapabilities(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"transitions":       domain.Transitions,
+		"defaultTransition": domain.TransitionFade,
+		"subtitleModes":     domain.SubtitleModes,
+		"fitModes":          domain.FitModes,
+		"framePresets":      domain.FramePresets,
+		"outputFormats":     domain.OutputFormats,
+		"qualityProfiles":   domain.QualityProfiles,
+		"qualityCRF":        domain.QualityCRF,
+		"defaultQuality":    domain.DefaultQuality,
+		"presets":           domain.Presets,
+		"profiles":          domain.H264Profiles,
+		"speakNotes":        h.ttsEngine != "",
+		"ttsEngine":         h.ttsEngine,
+	})
+}
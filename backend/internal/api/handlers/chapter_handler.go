@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"pptx2mp4/backend/internal/domain"
+	"pptx2mp4/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ChapterHandler struct {
+	jobService service.JobService
+	logger     *logrus.Logger
+}
+
+func NewChapterHandler(jobService service.JobService, logger *logrus.Logger) *ChapterHandler {
+	return &ChapterHandler{
+		jobService: jobService,
+		logger:     logger,
+	}
+}
+
+func (h *ChapterHandler) HandleChapters(c *gin.Context) {
+	jobID := c.Param("jobId")
+
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ungültige Request",
+			"message": "Job-ID fehlt",
+		})
+		return
+	}
+
+	job, err := h.jobService.GetJob(jobID)
+	if err != nil {
+		if err == domain.ErrJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Job nicht gefunden",
+				"message": "Der angeforderte Job existiert nicht",
+			})
+			return
+		}
+
+		h.logger.WithError(err).Error("fehler beim Abrufen des Jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Serverfehler",
+			"message": "Job konnte nicht abgerufen werden",
+		})
+		return
+	}
+
+	if !job.IsCompleted() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Job nicht abgeschlossen",
+			"message": "Die Konvertierung ist noch nicht abgeschlossen",
+			"status":  job.Status,
+		})
+		return
+	}
+
+	if len(job.Chapters) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Keine Kapitel",
+			"message": "Für diesen Job wurden keine Kapitelmarken erzeugt",
+		})
+		return
+	}
+
+	youtube := domain.FormatYouTubeChapters(job.Chapters)
+
+	if c.Query("format") == "youtube" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(youtube))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobId":    job.ID,
+		"chapters": job.Chapters,
+		"youtube":  youtube,
+	})
+}
@@ -67,7 +67,8 @@ func (h *DownloadHandler) HandleDownload(c *gin.Context) {
 		return
 	}
 
-	outputFile, err := h.fileService.GetOutputFile(jobID)
+	format := job.Config.FormatSpec()
+	outputFile, err := h.fileService.GetOutputFile(jobID, format.Extension)
 	if err != nil {
 		if err == domain.ErrFileNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -87,7 +88,7 @@ func (h *DownloadHandler) HandleDownload(c *gin.Context) {
 
 	originalName := filepath.Base(job.OriginalFile)
 	baseName := strings.TrimSuffix(originalName, filepath.Ext(originalName))
-	downloadName := baseName + ".mp4"
+	downloadName := baseName + format.Extension
 
 	h.logger.WithFields(logrus.Fields{
 		"jobID":        jobID,
@@ -95,10 +96,13 @@ func (h *DownloadHandler) HandleDownload(c *gin.Context) {
 		"downloadName": downloadName,
 	}).Info("starte Download")
 
-	c.Header("Content-Type", "video/mp4")
+	c.Header("Content-Type", format.ContentType)
 	c.FileAttachment(outputFile, downloadName)
 
 	if err := h.fileService.CleanupJob(jobID); err != nil {
 		h.logger.WithError(err).WithField("jobID", jobID).Warn("fehler beim Bereinigen der Job-Dateien")
 	}
+	if _, err := h.jobService.MarkDownloaded(jobID); err != nil {
+		h.logger.WithError(err).WithField("jobID", jobID).Warn("fehler beim Vermerken des Downloads")
+	}
 }
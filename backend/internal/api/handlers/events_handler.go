@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"pptx2mp4/backend/internal/domain"
+	"pptx2mp4/backend/internal/service"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// eventsKeepAlive hält die Verbindung bei langen Schritten ohne Fortschritt
+// offen, damit Proxies sie nicht wegen Inaktivität schließen.
+const eventsKeepAlive = 15 * time.Second
+
+type EventsHandler struct {
+	jobService service.JobService
+	logger     *logrus.Logger
+}
+
+func NewEventsHandler(jobService service.JobService, logger *logrus.Logger) *EventsHandler {
+	return &EventsHandler{
+		jobService: jobService,
+		logger:     logger,
+	}
+}
+
+// HandleEvents liefert Status, Schritt und Fortschritt eines Jobs als
+// Server-Sent Events. Der Stream endet mit dem Ereignis completed, failed
+// oder cancelled; completed enthält die Download-URL.
+func (h *EventsHandler) HandleEvents(c *gin.Context) {
+	jobID := c.Param("jobId")
+
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ungültige Request",
+			"message": "Job-ID fehlt",
+		})
+		return
+	}
+
+	events, unsubscribe, err := h.jobService.Subscribe(jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Job nicht gefunden",
+				"message": "Der angeforderte Job existiert nicht",
+			})
+			return
+		}
+
+		h.logger.WithError(err).Error("fehler beim Abrufen des Jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Serverfehler",
+			"message": "Job konnte nicht abgerufen werden",
+		})
+		return
+	}
+	defer unsubscribe()
+
+	downloadURL := strings.TrimSuffix(c.Request.URL.Path, "/events") + "/download"
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Verhindert, dass nginx die Ereignisse puffert.
+	c.Header("X-Accel-Buffering", "no")
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-events:
+			if event.Type == domain.JobEventCompleted {
+				event.DownloadURL = downloadURL
+			}
+			c.SSEvent(string(event.Type), event)
+			return !event.IsFinal()
+		case <-keepAlive.C:
+			// Kommentarzeile, wird von EventSource ignoriert.
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pptx2mp4/backend/internal/domain"
+	"pptx2mp4/backend/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type RetryHandler struct {
+	jobService service.JobService
+	logger     *logrus.Logger
+}
+
+func NewRetryHandler(jobService service.JobService, logger *logrus.Logger) *RetryHandler {
+	return &RetryHandler{
+		jobService: jobService,
+		logger:     logger,
+	}
+}
+
+// HandleRetry reiht einen fehlgeschlagenen Job erneut ein. Schritte, die
+// beim letzten Versuch abgeschlossen wurden, werden nicht wiederholt.
+func (h *RetryHandler) HandleRetry(c *gin.Context) {
+	jobID := c.Param("jobId")
+
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ungültige Request",
+			"message": "Job-ID fehlt",
+		})
+		return
+	}
+
+	job, err := h.jobService.RetryJob(jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Job nicht gefunden",
+				"message": "Der angeforderte Job existiert nicht",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidJobStatus) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Job nicht wiederholbar",
+				"message": "Nur fehlgeschlagene Jobs können wiederholt werden",
+				"status":  job.Status,
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrQueueFull) {
+			c.Header("Retry-After", strconv.Itoa(queueRetryAfter))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Server ausgelastet",
+				"message": "Die Warteschlange ist voll, bitte später erneut versuchen",
+			})
+			return
+		}
+
+		h.logger.WithError(err).Error("fehler beim Wiederholen des Jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Serverfehler",
+			"message": "Job konnte nicht wiederholt werden",
+		})
+		return
+	}
+
+	response := gin.H{
+		"jobId":  job.ID,
+		"status": job.Status,
+	}
+	if position, ok := h.jobService.QueuePosition(job.ID); ok {
+		response["queuePosition"] = position
+	}
+	c.JSON(http.StatusAccepted, response)
+}
@@ -56,6 +56,18 @@ func (h *StatusHandler) HandleStatus(c *gin.Context) {
 		"progress": job.Progress,
 	}
 
+	if position, ok := h.jobService.QueuePosition(jobID); ok {
+		response["queuePosition"] = position
+	}
+
+	if job.IsProcessing() && job.Stage != "" {
+		response["stage"] = job.Stage
+	}
+
+	if eta, ok := job.ETA(); ok {
+		response["etaSeconds"] = int(eta.Seconds())
+	}
+
 	if job.Error != "" {
 		response["error"] = job.Error
 	}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"pptx2mp4/backend/internal/domain"
+	"pptx2mp4/backend/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type SubtitleHandler struct {
+	jobService service.JobService
+	logger     *logrus.Logger
+}
+
+func NewSubtitleHandler(jobService service.JobService, logger *logrus.Logger) *SubtitleHandler {
+	return &SubtitleHandler{
+		jobService: jobService,
+		logger:     logger,
+	}
+}
+
+func (h *SubtitleHandler) HandleSubtitles(c *gin.Context) {
+	jobID := c.Param("jobId")
+	format := c.Param("format")
+
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ungültige Request",
+			"message": "Job-ID fehlt",
+		})
+		return
+	}
+
+	if format != "vtt" && format != "srt" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ungültiges Format",
+			"message": "Unterstützte Formate: vtt, srt",
+		})
+		return
+	}
+
+	job, err := h.jobService.GetJob(jobID)
+	if err != nil {
+		if err == domain.ErrJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Job nicht gefunden",
+				"message": "Der angeforderte Job existiert nicht",
+			})
+			return
+		}
+
+		h.logger.WithError(err).Error("fehler beim Abrufen des Jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Serverfehler",
+			"message": "Job konnte nicht abgerufen werden",
+		})
+		return
+	}
+
+	if !job.IsCompleted() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Job nicht abgeschlossen",
+			"message": "Die Konvertierung ist noch nicht abgeschlossen",
+			"status":  job.Status,
+		})
+		return
+	}
+
+	if len(job.Subtitles) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Keine Untertitel",
+			"message": "Für diesen Job wurden keine Untertitel erzeugt",
+		})
+		return
+	}
+
+	originalName := filepath.Base(job.OriginalFile)
+	baseName := strings.TrimSuffix(originalName, filepath.Ext(originalName))
+	downloadName := baseName + "." + format
+
+	content := domain.FormatWebVTT(job.Subtitles)
+	contentType := "text/vtt; charset=utf-8"
+	if format == "srt" {
+		content = domain.FormatSRT(job.Subtitles)
+		contentType = "application/x-subrip; charset=utf-8"
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+downloadName+`"`)
+	c.Data(http.StatusOK, contentType, []byte(content))
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"pptx2mp4/backend/internal/domain"
 	"pptx2mp4/backend/internal/service"
@@ -10,29 +13,64 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// queueRetryAfter ist die Wartezeit in Sekunden, die bei voller
+// Warteschlange im Retry-After-Header empfohlen wird.
+const queueRetryAfter = 30
+
 type UploadHandler struct {
 	fileService service.FileService
 	jobService  service.JobService
-	logger      *logrus.Logger
+	// ttsEngine ist die konfigurierte Sprachausgabe, leer wenn keine.
+	ttsEngine string
+	logger    *logrus.Logger
 }
 
 func NewUploadHandler(
 	fileService service.FileService,
 	jobService service.JobService,
+	ttsEngine string,
 	logger *logrus.Logger,
 ) *UploadHandler {
 	return &UploadHandler{
 		fileService: fileService,
 		jobService:  jobService,
+		ttsEngine:   ttsEngine,
 		logger:      logger,
 	}
 }
 
 type ConvertRequest struct {
-	FPS                int     `form:"fps" binding:"required,min=1,max=60"`
-	Resolution         int     `form:"resolution" binding:"required,oneof=720 1080 1440 2160"`
-	Duration           int     `form:"duration" binding:"required,min=1,max=60"`
-	TransitionDuration float64 `form:"transitionDuration" binding:"min=0,max=3"`
+	FPS                int      `form:"fps" binding:"required,min=1,max=60"`
+	Resolution         int      `form:"resolution" binding:"required_without_all=Width Height,omitempty,oneof=720 1080 1440 2160"`
+	Width              int      `form:"width" binding:"required_with=Height,omitempty,min=240,max=4096"`
+	Height             int      `form:"height" binding:"required_with=Width,omitempty,min=240,max=4096"`
+	Duration           int      `form:"duration" binding:"required,min=1,max=60"`
+	TransitionDuration float64  `form:"transitionDuration" binding:"min=0,max=3"`
+	UseDeckTimings     bool     `form:"useDeckTimings"`
+	SlideDurations     string   `form:"slideDurations"`
+	AudioFadeDuration  *float64 `form:"audioFadeDuration"`
+	UseNarration       bool     `form:"useNarration"`
+	NarrationPadding   *float64 `form:"narrationPadding"`
+	SpeakNotes         bool     `form:"speakNotes"`
+	Language           string   `form:"language"`
+	Voice              string   `form:"voice"`
+	SubtitleMode       string   `form:"subtitleMode"`
+	Chapters           *bool    `form:"chapters"`
+	Transition         string   `form:"transition"`
+	SlideTransitions   string   `form:"slideTransitions"`
+	FitMode            string   `form:"fitMode"`
+	BackgroundColor    string   `form:"backgroundColor"`
+	OutputFormat       string   `form:"outputFormat"`
+	Quality            string   `form:"quality"`
+	CRF                *int     `form:"crf"`
+	VideoBitrate       *int     `form:"videoBitrate"`
+	Preset             string   `form:"preset"`
+	MaxRate            *int     `form:"maxRate"`
+	BufSize            *int     `form:"bufSize"`
+	TwoPass            *bool    `form:"twoPass"`
+	Profile            string   `form:"profile"`
+	Level              string   `form:"level"`
+	FastStart          *bool    `form:"fastStart"`
 }
 
 func (h *UploadHandler) HandleUpload(c *gin.Context) {
@@ -46,6 +84,22 @@ func (h *UploadHandler) HandleUpload(c *gin.Context) {
 		return
 	}
 
+	if req.SpeakNotes && h.ttsEngine == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Sprachausgabe nicht verfügbar",
+			"message": "Auf dem Server ist keine Sprachausgabe (TTS_ENGINE) konfiguriert",
+		})
+		return
+	}
+	// Ohne Stimme schlüge der Job sonst erst bei der Sprachausgabe fehl.
+	if req.SpeakNotes && domain.VoiceRequired(h.ttsEngine) && req.Voice == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Sprachausgabe nicht verfügbar",
+			"message": fmt.Sprintf("%v: %s benötigt eine Stimme (voice)", domain.ErrTTSNotConfigured, h.ttsEngine),
+		})
+		return
+	}
+
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		h.logger.WithError(err).Error("keine Datei im Request")
@@ -65,8 +119,101 @@ func (h *UploadHandler) HandleUpload(c *gin.Context) {
 		return
 	}
 
-	config, err := domain.NewConversionConfig(req.FPS, req.Resolution, req.Duration, req.TransitionDuration)
+	audioHeader, err := c.FormFile("audio")
+	if err != nil && err != http.ErrMissingFile {
+		h.logger.WithError(err).Error("audiodatei konnte nicht gelesen werden")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Ungültige Audiodatei",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if audioHeader != nil {
+		if err := h.fileService.ValidateAudioUpload(audioHeader); err != nil {
+			h.logger.WithError(err).Warn("ungültige Audiodatei hochgeladen")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Ungültige Audiodatei",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	slideDurations, err := parseSlideDurations(req.SlideDurations)
+	if err != nil {
+		h.logger.WithError(err).Error("ungültige Slide-Dauern")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validierungsfehler",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	slideTransitions, err := parseSlideTransitions(req.SlideTransitions)
 	if err != nil {
+		h.logger.WithError(err).Error("ungültige Slide-Übergänge")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validierungsfehler",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	config := &domain.ConversionConfig{
+		FPS:                req.FPS,
+		Resolution:         req.Resolution,
+		Width:              req.Width,
+		Height:             req.Height,
+		Duration:           req.Duration,
+		TransitionDuration: req.TransitionDuration,
+		UseDeckTimings:     req.UseDeckTimings,
+		SlideDurations:     slideDurations,
+		AudioFadeDuration:  domain.DefaultAudioFade,
+		UseNarration:       req.UseNarration,
+		NarrationPadding:   domain.DefaultNarrationPadding,
+		SpeakNotes:         req.SpeakNotes,
+		SubtitleMode:       req.SubtitleMode,
+		Transition:         domain.TransitionFade,
+		SlideTransitions:   slideTransitions,
+		FitMode:            domain.FitModeLetterbox,
+		BackgroundColor:    domain.DefaultBackgroundColor,
+		OutputFormat:       domain.OutputFormatMP4,
+		Quality:            domain.DefaultQuality,
+		Voice: domain.VoiceConfig{
+			Language: domain.DefaultLanguage,
+			Voice:    req.Voice,
+		},
+	}
+	if req.Transition != "" {
+		config.Transition = req.Transition
+	}
+	if req.FitMode != "" {
+		config.FitMode = req.FitMode
+	}
+	if req.BackgroundColor != "" {
+		config.BackgroundColor = req.BackgroundColor
+	}
+	if req.OutputFormat != "" {
+		config.OutputFormat = req.OutputFormat
+	}
+	if req.Quality != "" {
+		config.Quality = req.Quality
+	}
+	config.Encoder = encoderSettings(config.Quality, config.Format(), &req)
+	if req.Chapters != nil {
+		config.Chapters = *req.Chapters
+	}
+	if req.Language != "" {
+		config.Voice.Language = req.Language
+	}
+	if req.AudioFadeDuration != nil {
+		config.AudioFadeDuration = *req.AudioFadeDuration
+	}
+	if req.NarrationPadding != nil {
+		config.NarrationPadding = *req.NarrationPadding
+	}
+	if err := config.Validate(); err != nil {
 		h.logger.WithError(err).Error("ungültige Konfiguration")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Ungültige Konfiguration",
@@ -86,7 +233,28 @@ func (h *UploadHandler) HandleUpload(c *gin.Context) {
 		return
 	}
 
-	if err := h.jobService.CreateJob(job); err != nil {
+	if audioHeader != nil {
+		if _, err := h.fileService.SaveAudioUpload(job.ID, audioHeader); err != nil {
+			h.logger.WithError(err).Error("fehler beim Speichern der Audiodatei")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Speicherfehler",
+				"message": "Audiodatei konnte nicht gespeichert werden",
+			})
+			return
+		}
+		job.AudioFile = audioHeader.Filename
+	}
+
+	if err := h.jobService.SubmitJob(job); err != nil {
+		if errors.Is(err, domain.ErrQueueFull) {
+			c.Header("Retry-After", strconv.Itoa(queueRetryAfter))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Server ausgelastet",
+				"message": "Die Warteschlange ist voll, bitte später erneut versuchen",
+			})
+			return
+		}
+
 		h.logger.WithError(err).Error("fehler beim Erstellen des Jobs")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Job-Erstellungsfehler",
@@ -95,24 +263,99 @@ func (h *UploadHandler) HandleUpload(c *gin.Context) {
 		return
 	}
 
-	go func() {
-		if err := h.jobService.ProcessJob(job.ID); err != nil {
-			h.logger.WithError(err).WithField("jobID", job.ID).Error("Job-Verarbeitung fehlgeschlagen")
-		}
-	}()
-
 	h.logger.WithFields(logrus.Fields{
-		"jobID":    job.ID,
-		"filename": fileHeader.Filename,
-		"fps":      req.FPS,
+		"jobID":      job.ID,
+		"filename":   fileHeader.Filename,
+		"fps":        req.FPS,
 		"resolution": req.Resolution,
-		"duration": req.Duration,
+		"width":      req.Width,
+		"height":     req.Height,
+		"duration":   req.Duration,
 	}).Info("Job erfolgreich erstellt")
 
-	c.JSON(http.StatusAccepted, gin.H{
+	response := gin.H{
 		"jobId":  job.ID,
 		"status": job.Status,
-	})
+	}
+	if position, ok := h.jobService.QueuePosition(job.ID); ok {
+		response["queuePosition"] = position
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+// encoderSettings übernimmt das gewählte Qualitätsprofil für das
+// Ausgabeformat und überschreibt die im Request angegebenen Einzelwerte.
+func encoderSettings(quality, format string, req *ConvertRequest) domain.EncoderSettings {
+	settings := domain.QualityProfile(quality, format)
+
+	if req.CRF != nil {
+		settings.CRF = *req.CRF
+	}
+	if req.VideoBitrate != nil {
+		settings.VideoBitrate = *req.VideoBitrate
+	}
+	if req.Preset != "" {
+		settings.Preset = req.Preset
+	}
+	if req.MaxRate != nil {
+		settings.MaxRate = *req.MaxRate
+	}
+	if req.BufSize != nil {
+		settings.BufSize = *req.BufSize
+	}
+	if req.TwoPass != nil {
+		settings.TwoPass = *req.TwoPass
+	}
+	if req.Profile != "" {
+		settings.Profile = req.Profile
+	}
+	if req.Level != "" {
+		settings.Level = req.Level
+	}
+	if req.FastStart != nil {
+		settings.FastStart = *req.FastStart
+	}
+
+	// Eine ausdrücklich gewählte Bitrate geht der Obergrenze des Profils
+	// vor, sofern keine eigene Obergrenze angegeben ist.
+	if req.VideoBitrate != nil && req.MaxRate == nil && settings.MaxRate > 0 && settings.MaxRate < settings.VideoBitrate {
+		settings.MaxRate = settings.VideoBitrate
+		if req.BufSize == nil {
+			settings.BufSize = 2 * settings.VideoBitrate
+		}
+	}
+
+	return settings
+}
+
+// parseSlideDurations liest die Slide-Dauern als JSON-Objekt,
+// z.B. {"3": 12.5, "7": 2}.
+func parseSlideDurations(value string) (map[int]float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var durations map[int]float64
+	if err := json.Unmarshal([]byte(value), &durations); err != nil {
+		return nil, fmt.Errorf("slideDurations ist kein gültiges JSON-Objekt: %w", err)
+	}
+
+	return durations, nil
+}
+
+// parseSlideTransitions liest die Übergänge je Slide als JSON-Objekt,
+// z.B. {"2": "cut", "5": "wipeleft"}.
+func parseSlideTransitions(value string) (map[int]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var transitions map[int]string
+	if err := json.Unmarshal([]byte(value), &transitions); err != nil {
+		return nil, fmt.Errorf("slideTransitions ist kein gültiges JSON-Objekt: %w", err)
+	}
+
+	return transitions, nil
 }
 
 func parseIntParam(c *gin.Context, key string, defaultValue int) int {
@@ -13,21 +13,33 @@ import (
 )
 
 type Router struct {
-	engine          *gin.Engine
-	uploadHandler   *handlers.UploadHandler
-	statusHandler   *handlers.StatusHandler
-	downloadHandler *handlers.DownloadHandler
-	healthHandler   *handlers.HealthHandler
-	logger          *logrus.Logger
-	allowedOrigins  []string
-	staticFiles     fs.FS
-	basePath        string
+	engine              *gin.Engine
+	uploadHandler       *handlers.UploadHandler
+	statusHandler       *handlers.StatusHandler
+	downloadHandler     *handlers.DownloadHandler
+	subtitleHandler     *handlers.SubtitleHandler
+	chapterHandler      *handlers.ChapterHandler
+	cancelHandler       *handlers.CancelHandler
+	retryHandler        *handlers.RetryHandler
+	eventsHandler       *handlers.EventsHandler
+	capabilitiesHandler *handlers.CapabilitiesHandler
+	healthHandler       *handlers.HealthHandler
+	logger              *logrus.Logger
+	allowedOrigins      []string
+	staticFiles         fs.FS
+	basePath            string
 }
 
 func NewRouter(
 	uploadHandler *handlers.UploadHandler,
 	statusHandler *handlers.StatusHandler,
 	downloadHandler *handlers.DownloadHandler,
+	subtitleHandler *handlers.SubtitleHandler,
+	chapterHandler *handlers.ChapterHandler,
+	cancelHandler *handlers.CancelHandler,
+	retryHandler *handlers.RetryHandler,
+	eventsHandler *handlers.EventsHandler,
+	capabilitiesHandler *handlers.CapabilitiesHandler,
 	healthHandler *handlers.HealthHandler,
 	logger *logrus.Logger,
 	allowedOrigins []string,
@@ -35,14 +47,20 @@ func NewRouter(
 	basePath string,
 ) *Router {
 	return &Router{
-		uploadHandler:   uploadHandler,
-		statusHandler:   statusHandler,
-		downloadHandler: downloadHandler,
-		healthHandler:   healthHandler,
-		logger:          logger,
-		allowedOrigins:  allowedOrigins,
-		staticFiles:     staticFiles,
-		basePath:        strings.TrimRight(basePath, "/"),
+		uploadHandler:       uploadHandler,
+		statusHandler:       statusHandler,
+		downloadHandler:     downloadHandler,
+		subtitleHandler:     subtitleHandler,
+		chapterHandler:      chapterHandler,
+		cancelHandler:       cancelHandler,
+		retryHandler:        retryHandler,
+		eventsHandler:       eventsHandler,
+		capabilitiesHandler: capabilitiesHandler,
+		healthHandler:       healthHandler,
+		logger:              logger,
+		allowedOrigins:      allowedOrigins,
+		staticFiles:         staticFiles,
+		basePath:            strings.TrimRight(basePath, "/"),
 	}
 }
 
@@ -60,7 +78,13 @@ func (r *Router) Setup() *gin.Engine {
 	{
 		api.POST("/convert", r.uploadHandler.HandleUpload)
 		api.GET("/jobs/:jobId/status", r.statusHandler.HandleStatus)
+		api.GET("/jobs/:jobId/events", r.eventsHandler.HandleEvents)
 		api.GET("/jobs/:jobId/download", r.downloadHandler.HandleDownload)
+		api.GET("/jobs/:jobId/subtitles/:format", r.subtitleHandler.HandleSubtitles)
+		api.GET("/jobs/:jobId/chapters", r.chapterHandler.HandleChapters)
+		api.DELETE("/jobs/:jobId", r.cancelHandler.HandleCancel)
+		api.POST("/jobs/:jobId/retry", r.retryHandler.HandleRetry)
+		api.GET("/capabilities", r.capabilitiesHandler.HandleCapabilities)
 		api.GET("/health", r.healthHandler.HandleHealth)
 	}
 
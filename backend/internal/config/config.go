@@ -2,31 +2,84 @@ package config
 
 import (
 	"os"
+	"runtime"
 	"strconv"
 	"time"
 )
 
 type Config struct {
-	Port             string
-	StoragePath      string
-	MaxFileSize      int64
-	CleanupInterval  time.Duration
-	AllowedOrigins   []string
-	BasePath         string
-	LogLevel         string
-	LogFormat        string
+	Port            string
+	StoragePath     string
+	MaxFileSize     int64
+	CleanupInterval time.Duration
+	AllowedOrigins  []string
+	BasePath        string
+	LogLevel        string
+	LogFormat       string
+	TTSEngine       string
+	PiperModelDir   string
+	// EncoderParallelism ist die Zahl gleichzeitiger ffmpeg-Prozesse je Job.
+	EncoderParallelism int
+	// RasterParallelism ist die Zahl gleichzeitiger pdftoppm-Prozesse je Job.
+	RasterParallelism int
+	// OfficePoolSize > 0 hält so viele LibreOffice-Instanzen warm (unoserver),
+	// sonst wird soffice pro Job gestartet.
+	OfficePoolSize          int
+	OfficeBasePort          int
+	OfficeMaxConversions    int
+	OfficeConversionTimeout time.Duration
+	// JobStore wählt die Ablage der Jobs: "bolt" speichert sie in der
+	// Datenbank STORAGE_PATH/jobs.db, "memory" nur im Speicher.
+	JobStore string
+	// JobWorkers ist die Zahl gleichzeitig verarbeiteter Jobs, weitere warten
+	// in einer Warteschlange mit höchstens MaxQueueLength Einträgen.
+	JobWorkers     int
+	MaxQueueLength int
+	// JobTimeout begrenzt die Gesamtlaufzeit eines Jobs, die übrigen
+	// Timeouts die einzelnen Schritte der Pipeline.
+	JobTimeout       time.Duration
+	PDFTimeout       time.Duration
+	RasterTimeout    time.Duration
+	NarrationTimeout time.Duration
+	EncodeTimeout    time.Duration
+	// Nach Ablauf dieser Zeiten löscht das Aufräumen alle CleanupInterval
+	// beendete Jobs samt Dateien und verwaiste Job-Verzeichnisse.
+	FailedJobTTL     time.Duration
+	CompletedJobTTL  time.Duration
+	DownloadedJobTTL time.Duration
+	OrphanTTL        time.Duration
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		Port:             getEnv("PORT", "8080"),
-		StoragePath:      getEnv("STORAGE_PATH", "./storage"),
-		MaxFileSize:      getEnvAsInt64("MAX_FILE_SIZE", 100*1024*1024),
-		CleanupInterval:  getEnvAsDuration("CLEANUP_INTERVAL", time.Hour),
-		AllowedOrigins:   getEnvAsSlice("ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
-		BasePath:         getEnv("BASE_PATH", "/pptx2mp4"),
-		LogLevel:         getEnv("LOG_LEVEL", "info"),
-		LogFormat:        getEnv("LOG_FORMAT", "json"),
+		Port:                    getEnv("PORT", "8080"),
+		StoragePath:             getEnv("STORAGE_PATH", "./storage"),
+		MaxFileSize:             getEnvAsInt64("MAX_FILE_SIZE", 100*1024*1024),
+		CleanupInterval:         getEnvAsDuration("CLEANUP_INTERVAL", time.Hour),
+		AllowedOrigins:          getEnvAsSlice("ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
+		BasePath:                getEnv("BASE_PATH", "/pptx2mp4"),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		LogFormat:               getEnv("LOG_FORMAT", "json"),
+		TTSEngine:               getEnv("TTS_ENGINE", ""),
+		PiperModelDir:           getEnv("PIPER_MODEL_DIR", "/app/voices"),
+		EncoderParallelism:      getEnvAsInt("ENCODER_PARALLELISM", runtime.NumCPU()),
+		RasterParallelism:       getEnvAsInt("RASTER_PARALLELISM", runtime.NumCPU()),
+		OfficePoolSize:          getEnvAsInt("OFFICE_POOL_SIZE", 0),
+		OfficeBasePort:          getEnvAsInt("OFFICE_BASE_PORT", 2003),
+		OfficeMaxConversions:    getEnvAsInt("OFFICE_MAX_CONVERSIONS", 100),
+		OfficeConversionTimeout: getEnvAsDuration("OFFICE_CONVERSION_TIMEOUT", 5*time.Minute),
+		JobStore:                getEnv("JOB_STORE", "bolt"),
+		JobWorkers:              getEnvAsInt("JOB_WORKERS", 2),
+		MaxQueueLength:          getEnvAsInt("MAX_QUEUE_LENGTH", 20),
+		JobTimeout:              getEnvAsDuration("JOB_TIMEOUT", time.Hour),
+		PDFTimeout:              getEnvAsDuration("PDF_TIMEOUT", 10*time.Minute),
+		RasterTimeout:           getEnvAsDuration("RASTER_TIMEOUT", 10*time.Minute),
+		NarrationTimeout:        getEnvAsDuration("NARRATION_TIMEOUT", 10*time.Minute),
+		EncodeTimeout:           getEnvAsDuration("ENCODE_TIMEOUT", 45*time.Minute),
+		FailedJobTTL:            getEnvAsDuration("FAILED_JOB_TTL", 24*time.Hour),
+		CompletedJobTTL:         getEnvAsDuration("COMPLETED_JOB_TTL", 24*time.Hour),
+		DownloadedJobTTL:        getEnvAsDuration("DOWNLOADED_JOB_TTL", 24*time.Hour),
+		OrphanTTL:               getEnvAsDuration("ORPHAN_TTL", 24*time.Hour),
 	}
 }
 
@@ -52,6 +105,20 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return value
 }
 
+func getEnvAsInt(key string, defaultValue int) int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	loudnessFilter = "loudnorm=I=-16:TP=-1.5:LRA=11"
+	// backgroundDuckVolume senkt die Hintergrundmusik unter Kommentare ab.
+	backgroundDuckVolume = 0.25
+)
+
+// buildAudioFilter erzeugt die zusätzlichen Eingaben und den Filtergraphen für
+// Kommentare und Hintergrundmusik. firstInput ist der Index der ersten
+// Audio-Eingabe. Ohne Audio ist das gelieferte Label leer.
+func buildAudioFilter(request *EncodeRequest, firstInput int) ([]string, []string, string) {
+	var args, filterParts, mixLabels []string
+	input := firstInput
+
+	totalDuration := request.Timeline.TotalDuration()
+	starts := request.Timeline.StartTimes()
+
+	for i, timing := range request.Timeline {
+		if timing.Narration == "" {
+			continue
+		}
+
+		delay := int((starts[i] + timing.TransitionDuration) * 1000)
+		label := fmt.Sprintf("n%d", i)
+		args = append(args, "-i", timing.Narration)
+		filterParts = append(filterParts,
+			fmt.Sprintf("[%d:a]aresample=48000,adelay=%d:all=1[%s]", input, delay, label))
+		mixLabels = append(mixLabels, label)
+		input++
+	}
+	hasNarration := len(mixLabels) > 0
+
+	if request.BackgroundAudio != "" {
+		args = append(args, "-stream_loop", "-1", "-i", request.BackgroundAudio)
+		filterParts = append(filterParts,
+			buildBackgroundAudioFilter(input, totalDuration, request.Config.AudioFadeDuration, hasNarration, "bg"))
+		mixLabels = append(mixLabels, "bg")
+	}
+
+	if len(mixLabels) == 0 {
+		return nil, nil, ""
+	}
+
+	mixed := "[" + mixLabels[0] + "]"
+	if len(mixLabels) > 1 {
+		mixed = "[" + strings.Join(mixLabels, "][") + "]" +
+			fmt.Sprintf("amix=inputs=%d:normalize=0:duration=longest,", len(mixLabels))
+	}
+
+	filterParts = append(filterParts,
+		fmt.Sprintf("%sapad,atrim=0:%.4f,asetpts=PTS-STARTPTS,%s,aresample=48000[aout]",
+			mixed, totalDuration, loudnessFilter))
+
+	return args, filterParts, "aout"
+}
+
+// buildBackgroundAudioFilter kürzt die (in Schleife gelesene) Musik auf die
+// Videolänge und blendet sie ein und aus.
+func buildBackgroundAudioFilter(input int, totalDuration, fadeDuration float64, duck bool, label string) string {
+	fadeDuration = min(fadeDuration, totalDuration/2)
+
+	filters := []string{
+		"aresample=48000",
+		fmt.Sprintf("atrim=0:%.4f", totalDuration),
+		"asetpts=PTS-STARTPTS",
+	}
+	if fadeDuration > 0 {
+		filters = append(filters,
+			fmt.Sprintf("afade=t=in:st=0:d=%.4f", fadeDuration),
+			fmt.Sprintf("afade=t=out:st=%.4f:d=%.4f", totalDuration-fadeDuration, fadeDuration))
+	}
+	if duck {
+		filters = append(filters, fmt.Sprintf("volume=%.2f", backgroundDuckVolume))
+	}
+
+	return fmt.Sprintf("[%d:a]%s[%s]", input, strings.Join(filters, ","), label)
+}
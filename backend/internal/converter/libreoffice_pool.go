@@ -0,0 +1,279 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pptx2mp4/backend/internal/domain"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	officeHost           = "127.0.0.1"
+	officeStartupTimeout = 60 * time.Second
+	officeStopTimeout    = 10 * time.Second
+)
+
+// LibreOfficePoolOptions konfiguriert den Pool warmer LibreOffice-Instanzen.
+type LibreOfficePoolOptions struct {
+	Size int
+	// BasePort ist der XML-RPC-Port der ersten Instanz. Jede Instanz belegt
+	// zwei aufeinanderfolgende Ports (XML-RPC und UNO).
+	BasePort int
+	// MaxConversions startet eine Instanz nach so vielen Konvertierungen neu,
+	// um Speicherlecks von LibreOffice zu begrenzen.
+	MaxConversions    int
+	ConversionTimeout time.Duration
+	// ProfileDir enthält je Instanz ein eigenes LibreOffice-Benutzerprofil.
+	ProfileDir string
+}
+
+// LibreOfficePool hält headless LibreOffice-Instanzen bereit, die über
+// unoserver Konvertierungen annehmen. Damit entfällt der Start von soffice
+// pro Job.
+type LibreOfficePool struct {
+	options   LibreOfficePoolOptions
+	instances chan *officeInstance
+	logger    *logrus.Logger
+}
+
+type officeInstance struct {
+	id          int
+	port        int
+	unoPort     int
+	profileDir  string
+	cmd         *exec.Cmd
+	exited      chan struct{}
+	conversions int
+}
+
+func NewLibreOfficePool(options LibreOfficePoolOptions, logger *logrus.Logger) *LibreOfficePool {
+	return &LibreOfficePool{
+		options:   options,
+		instances: make(chan *officeInstance, options.Size),
+		logger:    logger,
+	}
+}
+
+// Start startet alle Instanzen und wartet, bis sie Verbindungen annehmen.
+func (p *LibreOfficePool) Start() error {
+	for id := 0; id < p.options.Size; id++ {
+		instance := &officeInstance{
+			id:         id,
+			port:       p.options.BasePort + 2*id,
+			unoPort:    p.options.BasePort + 2*id + 1,
+			profileDir: filepath.Join(p.options.ProfileDir, fmt.Sprintf("instance-%d", id)),
+		}
+		if err := p.startInstance(instance); err != nil {
+			return err
+		}
+		p.instances <- instance
+	}
+
+	p.logger.WithField("size", p.options.Size).Info("LibreOffice-Pool gestartet")
+	return nil
+}
+
+func (p *LibreOfficePool) ConvertToPDF(ctx context.Context, inputPath, outputDir string) (string, error) {
+	var instance *officeInstance
+	select {
+	case instance = <-p.instances:
+	case <-ctx.Done():
+		return "", fmt.Errorf("%w: %w", domain.ErrPPTXConversion, ctx.Err())
+	}
+	defer func() { p.instances <- instance }()
+
+	logger := p.logger.WithFields(logrus.Fields{
+		"input":     inputPath,
+		"outputDir": outputDir,
+		"instance":  instance.id,
+	})
+	logger.Info("starte PPTX zu PDF Konvertierung")
+
+	if !p.healthy(instance) {
+		logger.Warn("LibreOffice-Instanz nicht erreichbar, starte neu")
+		if err := p.restartInstance(instance); err != nil {
+			return "", fmt.Errorf("%w: %v", domain.ErrPPTXConversion, err)
+		}
+	}
+
+	outputFile := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))+".pdf")
+
+	convertCtx, cancel := context.WithTimeout(ctx, p.options.ConversionTimeout)
+	defer cancel()
+
+	cmd := commandContext(convertCtx,
+		"unoconvert",
+		"--host", officeHost,
+		"--port", strconv.Itoa(instance.port),
+		"--convert-to", "pdf",
+		inputPath,
+		outputFile,
+	)
+	output, err := cmd.CombinedOutput()
+	instance.conversions++
+
+	if err != nil {
+		if errors.Is(convertCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			logger.WithField("timeout", p.options.ConversionTimeout).Error("zeitüberschreitung bei der PPTX zu PDF Konvertierung")
+		} else {
+			logger.WithError(err).WithField("output", string(output)).Error("PPTX zu PDF Konvertierung fehlgeschlagen")
+		}
+
+		// Nach einem Fehler ist der Zustand der Instanz unklar.
+		if restartErr := p.restartInstance(instance); restartErr != nil {
+			logger.WithError(restartErr).Error("LibreOffice-Instanz konnte nicht neu gestartet werden")
+		}
+		return "", commandFailed(convertCtx, domain.ErrPPTXConversion, output)
+	}
+
+	if _, err := os.Stat(outputFile); err != nil {
+		return "", fmt.Errorf("%w: PDF wurde nicht erzeugt", domain.ErrPPTXConversion)
+	}
+
+	if p.options.MaxConversions > 0 && instance.conversions >= p.options.MaxConversions {
+		logger.WithField("conversions", instance.conversions).Info("maximale Anzahl Konvertierungen erreicht, starte LibreOffice-Instanz neu")
+		if err := p.restartInstance(instance); err != nil {
+			logger.WithError(err).Error("LibreOffice-Instanz konnte nicht neu gestartet werden")
+		}
+	}
+
+	logger.WithField("output", outputFile).Info("PPTX zu PDF Konvertierung erfolgreich")
+	return outputFile, nil
+}
+
+// healthy prüft, ob der Prozess einer Instanz noch läuft und ihr Port
+// Verbindungen annimmt.
+func (p *LibreOfficePool) healthy(instance *officeInstance) bool {
+	select {
+	case <-instance.exited:
+		return false
+	default:
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(officeHost, strconv.Itoa(instance.port)), time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func (p *LibreOfficePool) startInstance(instance *officeInstance) error {
+	logger := p.logger.WithFields(logrus.Fields{
+		"instance": instance.id,
+		"port":     instance.port,
+	})
+
+	// Ein frisches Profil verhindert, dass ein beschädigtes Profil nach
+	// einem Absturz übernommen wird.
+	if err := os.RemoveAll(instance.profileDir); err != nil {
+		return fmt.Errorf("fehler beim Entfernen des LibreOffice-Profils: %w", err)
+	}
+	if err := os.MkdirAll(instance.profileDir, 0755); err != nil {
+		return fmt.Errorf("fehler beim Erstellen des LibreOffice-Profils: %w", err)
+	}
+	profileURL, err := fileURL(instance.profileDir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(
+		"unoserver",
+		"--interface", officeHost,
+		"--port", strconv.Itoa(instance.port),
+		"--uno-interface", officeHost,
+		"--uno-port", strconv.Itoa(instance.unoPort),
+		"--user-installation", profileURL,
+	)
+	// Eigene Prozessgruppe, damit beim Beenden auch soffice.bin mit
+	// beendet werden kann.
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("fehler beim Starten von unoserver: %w", err)
+	}
+
+	instance.cmd = cmd
+	instance.exited = make(chan struct{})
+	instance.conversions = 0
+	go func(exited chan struct{}) {
+		err := cmd.Wait()
+		logger.WithError(err).Debug("unoserver beendet")
+		close(exited)
+	}(instance.exited)
+
+	deadline := time.Now().Add(officeStartupTimeout)
+	for time.Now().Before(deadline) {
+		if p.healthy(instance) {
+			logger.Info("LibreOffice-Instanz bereit")
+			return nil
+		}
+
+		select {
+		case <-instance.exited:
+			return fmt.Errorf("unoserver wurde beim Start beendet")
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	p.stopInstance(instance)
+	return fmt.Errorf("unoserver ist nach %s nicht erreichbar", officeStartupTimeout)
+}
+
+func (p *LibreOfficePool) stopInstance(instance *officeInstance) {
+	if instance.cmd == nil || instance.cmd.Process == nil {
+		return
+	}
+
+	// unoserver beendet bei SIGTERM auch den soffice-Prozess. Reste der
+	// Prozessgruppe werden danach in jedem Fall beendet.
+	defer killProcessGroup(instance.cmd)
+
+	select {
+	case <-instance.exited:
+		return
+	default:
+	}
+
+	instance.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-instance.exited:
+	case <-time.After(officeStopTimeout):
+		killProcessGroup(instance.cmd)
+		<-instance.exited
+	}
+}
+
+func (p *LibreOfficePool) restartInstance(instance *officeInstance) error {
+	p.stopInstance(instance)
+	return p.startInstance(instance)
+}
+
+func (p *LibreOfficePool) IsAvailable() bool {
+	for _, tool := range []string{"unoserver", "unoconvert"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// fileURL wandelt einen Verzeichnispfad in eine file://-URL um, wie sie
+// LibreOffice für -env:UserInstallation erwartet.
+func fileURL(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("fehler beim Auflösen von %s: %w", path, err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String(), nil
+}
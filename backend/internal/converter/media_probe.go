@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type MediaProber interface {
+	ProbeDuration(ctx context.Context, mediaPath string) (float64, error)
+}
+
+type FFprobeProber struct {
+	logger *logrus.Logger
+}
+
+func NewFFprobeProber(logger *logrus.Logger) *FFprobeProber {
+	return &FFprobeProber{
+		logger: logger,
+	}
+}
+
+func (p *FFprobeProber) ProbeDuration(ctx context.Context, mediaPath string) (float64, error) {
+	cmd := commandContext(ctx,
+		"ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		mediaPath,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("fehler beim Abrufen der Mediendauer: %w", err)
+	}
+
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("fehler beim Parsen der Mediendauer: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"media":    mediaPath,
+		"duration": duration,
+	}).Debug("mediendauer ermittelt")
+
+	return duration, nil
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"pptx2mp4/backend/internal/domain"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type NarrationSynthesizer interface {
+	Synthesize(ctx context.Context, text, outputPath string, voice domain.VoiceConfig) error
+}
+
+// NewNarrationSynthesizer liefert die Engine für den konfigurierten Namen
+// oder nil, wenn keine Sprachausgabe konfiguriert ist.
+func NewNarrationSynthesizer(engine, piperModelDir string, logger *logrus.Logger) (NarrationSynthesizer, error) {
+	switch engine {
+	case "":
+		return nil, nil
+	case domain.TTSEngineEspeak:
+		return NewEspeakSynthesizer(logger), nil
+	case domain.TTSEnginePiper:
+		return NewPiperSynthesizer(piperModelDir, logger), nil
+	}
+	return nil, fmt.Errorf("unbekannte TTS-Engine: %s", engine)
+}
+
+type EspeakSynthesizer struct {
+	logger *logrus.Logger
+}
+
+func NewEspeakSynthesizer(logger *logrus.Logger) *EspeakSynthesizer {
+	return &EspeakSynthesizer{
+		logger: logger,
+	}
+}
+
+func (s *EspeakSynthesizer) Synthesize(ctx context.Context, text, outputPath string, voice domain.VoiceConfig) error {
+	// espeak-ng wählt die Stimme über die Sprache, Varianten werden mit "+" angehängt.
+	espeakVoice := voice.Language
+	if voice.Voice != "" {
+		espeakVoice += "+" + voice.Voice
+	}
+
+	cmd := commandContext(ctx, "espeak-ng", "-v", espeakVoice, "-w", outputPath, "--stdin")
+	cmd.Stdin = strings.NewReader(text)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		s.logger.WithError(err).WithField("output", string(output)).Error("sprachausgabe fehlgeschlagen")
+		return commandFailed(ctx, domain.ErrSpeechSynthesis, output)
+	}
+
+	return nil
+}
+
+func (s *EspeakSynthesizer) IsAvailable() bool {
+	cmd := exec.Command("espeak-ng", "--version")
+	err := cmd.Run()
+	return err == nil
+}
+
+type PiperSynthesizer struct {
+	modelDir string
+	logger   *logrus.Logger
+}
+
+func NewPiperSynthesizer(modelDir string, logger *logrus.Logger) *PiperSynthesizer {
+	return &PiperSynthesizer{
+		modelDir: modelDir,
+		logger:   logger,
+	}
+}
+
+func (s *PiperSynthesizer) Synthesize(ctx context.Context, text, outputPath string, voice domain.VoiceConfig) error {
+	// Piper-Stimmen sind sprachspezifische Modelle, z.B. "de_DE-thorsten-medium".
+	if voice.Voice == "" {
+		return fmt.Errorf("%w: piper benötigt eine Stimme", domain.ErrSpeechSynthesis)
+	}
+	model := filepath.Join(s.modelDir, voice.Voice+".onnx")
+
+	cmd := commandContext(ctx, "piper", "--model", model, "--output_file", outputPath)
+	cmd.Stdin = strings.NewReader(text)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		s.logger.WithError(err).WithField("output", string(output)).Error("sprachausgabe fehlgeschlagen")
+		return commandFailed(ctx, domain.ErrSpeechSynthesis, output)
+	}
+
+	return nil
+}
+
+func (s *PiperSynthesizer) IsAvailable() bool {
+	cmd := exec.Command("piper", "--help")
+	err := cmd.Run()
+	return err == nil
+}
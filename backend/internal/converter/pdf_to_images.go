@@ -1,55 +1,136 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"pptx2mp4/backend/internal/domain"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
 type PDFToImagesConverter interface {
-	ConvertToImages(pdfPath, outputDir string, resolution int) ([]string, error)
+	ConvertToImages(ctx context.Context, pdfPath, outputDir string, width, height int, progress ProgressFunc) ([]string, error)
+	GetPageSize(ctx context.Context, pdfPath string) (float64, float64, error)
 }
 
 type PopplerConverter struct {
-	logger *logrus.Logger
+	// parallelism begrenzt die Zahl gleichzeitig laufender pdftoppm-Prozesse.
+	parallelism int
+	logger      *logrus.Logger
 }
 
-func NewPopplerConverter(logger *logrus.Logger) *PopplerConverter {
+func NewPopplerConverter(parallelism int, logger *logrus.Logger) *PopplerConverter {
 	return &PopplerConverter{
-		logger: logger,
+		parallelism: max(parallelism, 1),
+		logger:      logger,
 	}
 }
 
-func (c *PopplerConverter) ConvertToImages(pdfPath, outputDir string, resolution int) ([]string, error) {
-	c.logger.WithFields(logrus.Fields{
-		"pdf":        pdfPath,
-		"outputDir":  outputDir,
-		"resolution": resolution,
-	}).Info("starte PDF zu Bilder Konvertierung")
+// pageRange ist ein zusammenhängender Seitenbereich für einen pdftoppm-Aufruf.
+type pageRange struct {
+	first, last int
+}
+
+// splitPages teilt die Seiten 1..pages in höchstens parts gleich große
+// Bereiche auf.
+func splitPages(pages, parts int) []pageRange {
+	parts = max(min(parts, pages), 1)
+	size := (pages + parts - 1) / parts
+
+	var ranges []pageRange
+	for first := 1; first <= pages; first += size {
+		ranges = append(ranges, pageRange{first: first, last: min(first+size-1, pages)})
+	}
+	return ranges
+}
+
+// ConvertToImages rendert jede Seite exakt in der Größe width x height.
+// Die Seiten werden in Bereiche aufgeteilt, die parallel von eigenen
+// pdftoppm-Prozessen gerendert werden. pdftoppm richtet die Nullen im
+// Dateinamen nach der Seitenzahl des gesamten Dokuments aus, die Namen
+// sind daher unabhängig von der Aufteilung. Schlägt ein Bereich fehl, werden
+// die übrigen abgebrochen. Der Fortschritt ergibt sich aus der Zahl bereits
+// geschriebener Bilder im Verhältnis zur Seitenzahl.
+func (c *PopplerConverter) ConvertToImages(ctx context.Context, pdfPath, outputDir string, width, height int, progress ProgressFunc) ([]string, error) {
+	logger := c.logger.WithFields(logrus.Fields{
+		"pdf":       pdfPath,
+		"outputDir": outputDir,
+		"width":     width,
+		"height":    height,
+	})
+	logger.Info("starte PDF zu Bilder Konvertierung")
 
 	outputPrefix := filepath.Join(outputDir, "slide")
 
-	// resolution ist die Zielhöhe in Pixeln (z.B. 1080).
-	// pdftoppm erwartet DPI. Ein Standard-16:9-Slide ist ~10" breit,
-	// also: DPI = (resolution * 16/9) / 10 = resolution * 16 / 90
-	dpi := resolution * 16 / 90
+	// Bilder eines früheren Versuchs verfälschten sonst den Fortschritt und
+	// das Ergebnis, falls sich die Seitenzahl geändert hat.
+	stale, err := filepath.Glob(filepath.Join(outputDir, "slide-*.png"))
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Suchen vorhandener Bilder: %w", err)
+	}
+	for _, image := range stale {
+		if err := os.Remove(image); err != nil {
+			return nil, fmt.Errorf("fehler beim Löschen vorhandener Bilder: %w", err)
+		}
+	}
 
-	cmd := exec.Command(
-		"pdftoppm",
-		"-png",
-		"-r", fmt.Sprintf("%d", dpi),
-		pdfPath,
-		outputPrefix,
+	ranges := []pageRange{{}}
+	pageCount, err := c.GetSlideCount(ctx, pdfPath)
+	if err != nil {
+		logger.WithError(err).Warn("seitenzahl nicht lesbar, rendere ohne Aufteilung")
+	} else {
+		ranges = splitPages(pageCount, c.parallelism)
+		logger.WithFields(logrus.Fields{
+			"pageCount":  pageCount,
+			"rangeCount": len(ranges),
+		}).Debug("seiten aufgeteilt")
+	}
+
+	renderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Der Beobachter muss beendet sein, bevor ConvertToImages zurückkehrt,
+	// sonst könnte er noch nach dem nächsten Schritt Fortschritt melden.
+	stopWatching := make(chan struct{})
+	watcherDone := make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		watchPages(outputDir, pageCount, progress, stopWatching)
+	}()
+	defer func() {
+		close(stopWatching)
+		<-watcherDone
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
 	)
+	for _, pages := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.renderPages(renderCtx, pdfPath, outputPrefix, width, height, pages); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		c.logger.WithError(err).WithField("output", string(output)).Error("PDF zu Bilder Konvertierung fehlgeschlagen")
-		return nil, fmt.Errorf("%w: %s", domain.ErrPDFConversion, string(output))
+	if firstErr != nil {
+		return nil, firstErr
 	}
 
 	images, err := filepath.Glob(filepath.Join(outputDir, "slide-*.png"))
@@ -63,32 +144,91 @@ func (c *PopplerConverter) ConvertToImages(pdfPath, outputDir string, resolution
 		return nil, fmt.Errorf("%w: keine Bilder generiert", domain.ErrPDFConversion)
 	}
 
+	sortSlideImages(images)
+
 	c.logger.WithField("imageCount", len(images)).Info("PDF zu Bilder Konvertierung erfolgreich")
 	return images, nil
 }
 
+// renderPages rendert einen Seitenbereich; ein leerer Bereich steht für
+// das ganze Dokument.
+func (c *PopplerConverter) renderPages(ctx context.Context, pdfPath, outputPrefix string, width, height int, pages pageRange) error {
+	args := []string{
+		"-png",
+		"-scale-to-x", strconv.Itoa(width),
+		"-scale-to-y", strconv.Itoa(height),
+	}
+	if pages.first > 0 {
+		args = append(args, "-f", strconv.Itoa(pages.first), "-l", strconv.Itoa(pages.last))
+	}
+	args = append(args, pdfPath, outputPrefix)
+
+	cmd := commandContext(ctx, "pdftoppm", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		c.logger.WithError(err).WithFields(logrus.Fields{
+			"output":    string(output),
+			"firstPage": pages.first,
+			"lastPage":  pages.last,
+		}).Error("PDF zu Bilder Konvertierung fehlgeschlagen")
+		return commandFailed(ctx, domain.ErrPDFConversion, output)
+	}
+
+	return nil
+}
+
 func (c *PopplerConverter) IsAvailable() bool {
 	cmd := exec.Command("pdftoppm", "-v")
 	err := cmd.Run()
 	return err == nil || cmd.ProcessState != nil
 }
 
-func (c *PopplerConverter) GetSlideCount(pdfPath string) (int, error) {
-	cmd := exec.Command("pdfinfo", pdfPath)
-	output, err := cmd.Output()
+// GetSlideCount liefert die Seitenzahl des PDFs.
+func (c *PopplerConverter) GetSlideCount(ctx context.Context, pdfPath string) (int, error) {
+	value, err := readPDFInfo(ctx, pdfPath, "Pages")
 	if err != nil {
-		return 0, fmt.Errorf("fehler beim Abrufen der PDF-Informationen: %w", err)
+		return 0, err
 	}
 
-	var pages int
-	_, err = fmt.Sscanf(string(output), "Pages: %d", &pages)
-	if err != nil {
-		return 0, fmt.Errorf("fehler beim Parsen der Seitenzahl: %w", err)
+	pages, err := strconv.Atoi(value)
+	if err != nil || pages < 1 {
+		return 0, fmt.Errorf("fehler beim Parsen der Seitenzahl: %q", value)
 	}
 
 	return pages, nil
 }
 
+// GetPageSize liefert Breite und Höhe der ersten Seite in Punkten.
+func (c *PopplerConverter) GetPageSize(ctx context.Context, pdfPath string) (float64, float64, error) {
+	value, err := readPDFInfo(ctx, pdfPath, "Page size")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var width, height float64
+	if _, err := fmt.Sscanf(value, "%g x %g", &width, &height); err != nil {
+		return 0, 0, fmt.Errorf("fehler beim Parsen der Seitengröße: %w", err)
+	}
+	return width, height, nil
+}
+
+// readPDFInfo liefert den Wert eines Feldes aus der Ausgabe von pdfinfo.
+func readPDFInfo(ctx context.Context, pdfPath, field string) (string, error) {
+	cmd := commandContext(ctx, "pdfinfo", pdfPath)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("fehler beim Abrufen der PDF-Informationen: %w", err)
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if value, ok := strings.CutPrefix(line, field+":"); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+
+	return "", fmt.Errorf("%s nicht in den PDF-Informationen enthalten", field)
+}
+
 func (c *PopplerConverter) CleanupImages(images []string) error {
 	for _, img := range images {
 		if err := os.Remove(img); err != nil {
@@ -1,16 +1,20 @@
 package converter
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"pptx2mp4/backend/internal/domain"
+	"regexp"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
 type PPTXConverter interface {
-	ConvertToPDF(inputPath, outputDir string) (string, error)
+	ConvertToPDF(ctx context.Context, inputPath, outputDir string) (string, error)
 }
 
 type LibreOfficeConverter struct {
@@ -23,32 +27,92 @@ func NewLibreOfficeConverter(logger *logrus.Logger) *LibreOfficeConverter {
 	}
 }
 
-func (c *LibreOfficeConverter) ConvertToPDF(inputPath, outputDir string) (string, error) {
+// ConvertToPDF startet soffice mit einem eigenen, temporären Benutzerprofil
+// im Ausgabeverzeichnis. Das gemeinsame Standardprofil ist gesperrt, solange
+// eine andere Instanz läuft; parallele Jobs würden sonst sofort abbrechen
+// oder kein PDF erzeugen.
+func (c *LibreOfficeConverter) ConvertToPDF(ctx context.Context, inputPath, outputDir string) (string, error) {
 	c.logger.WithFields(logrus.Fields{
 		"input":     inputPath,
 		"outputDir": outputDir,
 	}).Info("starte PPTX zu PDF Konvertierung")
 
-	cmd := exec.Command(
+	profileDir, err := os.MkdirTemp(outputDir, "lo-profile-")
+	if err != nil {
+		return "", fmt.Errorf("fehler beim Erstellen des LibreOffice-Profils: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(profileDir); err != nil {
+			c.logger.WithError(err).WithField("profileDir", profileDir).Warn("fehler beim Löschen des LibreOffice-Profils")
+		}
+	}()
+
+	profileURL, err := fileURL(profileDir)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := commandContext(ctx,
 		"soffice",
+		"-env:UserInstallation="+profileURL,
 		"--headless",
+		"--norestore",
 		"--convert-to", "pdf",
 		"--outdir", outputDir,
 		inputPath,
 	)
 
+	// Nach dem Ende von soffice wird die Prozessgruppe nicht mehr beendet:
+	// Ihre ID kann dann bereits neu vergeben sein. Zurückgebliebene
+	// Hilfsprozesse sperren nur das eigene, danach gelöschte Profil.
+	// Abbruch und Zeitüberschreitung beenden die Gruppe über commandContext,
+	// solange soffice noch läuft.
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		c.logger.WithError(err).WithField("output", string(output)).Error("PPTX zu PDF Konvertierung fehlgeschlagen")
-		return "", fmt.Errorf("%w: %s", domain.ErrPPTXConversion, string(output))
+		return "", commandFailed(ctx, domain.ErrPPTXConversion, output)
 	}
 
-	outputFile := filepath.Join(outputDir, "input.pdf")
+	outputFile, err := findConvertedPDF(string(output), inputPath, outputDir)
+	if err != nil {
+		c.logger.WithError(err).WithField("output", string(output)).Error("PPTX zu PDF Konvertierung fehlgeschlagen")
+		return "", err
+	}
 
 	c.logger.WithField("output", outputFile).Info("PPTX zu PDF Konvertierung erfolgreich")
 	return outputFile, nil
 }
 
+// convertedPattern erkennt die Meldung von soffice, z.B.
+// "convert /tmp/input.pptx -> /tmp/input.pdf using filter : impress_pdf_Export".
+var convertedPattern = regexp.MustCompile(`-> (.+\.pdf) using filter`)
+
+// findConvertedPDF ermittelt das tatsächlich erzeugte PDF. soffice meldet
+// den Pfad in seiner Ausgabe; fehlt die Meldung, wird der aus dem
+// Eingabenamen abgeleitete Name geprüft und zuletzt das einzige PDF im
+// Ausgabeverzeichnis verwendet.
+func findConvertedPDF(output, inputPath, outputDir string) (string, error) {
+	var candidates []string
+	if match := convertedPattern.FindStringSubmatch(output); match != nil {
+		candidates = append(candidates, match[1])
+	}
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	candidates = append(candidates, filepath.Join(outputDir, baseName+".pdf"))
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.Size() > 0 {
+			return candidate, nil
+		}
+	}
+
+	pdfs, err := filepath.Glob(filepath.Join(outputDir, "*.pdf"))
+	if err == nil && len(pdfs) == 1 {
+		return pdfs[0], nil
+	}
+
+	return "", fmt.Errorf("%w: kein PDF erzeugt", domain.ErrPPTXConversion)
+}
+
 func (c *LibreOfficeConverter) IsAvailable() bool {
 	cmd := exec.Command("soffice", "--version")
 	err := cmd.Run()
@@ -0,0 +1,489 @@
+package converter
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"pptx2mp4/backend/internal/domain"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	relTypeSlide      = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide"
+	relTypeNotesSlide = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide"
+	nsRelations       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+var narrationExtensions = []string{".m4a", ".mp3", ".wav", ".wma", ".aac"}
+
+type PPTXParser interface {
+	Parse(pptxPath string) (*domain.Deck, error)
+	ExtractPart(pptxPath, part, destPath string) error
+}
+
+type OOXMLParser struct {
+	logger *logrus.Logger
+}
+
+func NewOOXMLParser(logger *logrus.Logger) *OOXMLParser {
+	return &OOXMLParser{
+		logger: logger,
+	}
+}
+
+type presentationXML struct {
+	SlideIDs []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sldIdLst>sldId"`
+}
+
+type relationshipsXML struct {
+	Relationships []relationshipXML `xml:"Relationship"`
+}
+
+type relationshipXML struct {
+	ID         string `xml:"Id,attr"`
+	Type       string `xml:"Type,attr"`
+	Target     string `xml:"Target,attr"`
+	TargetMode string `xml:"TargetMode,attr"`
+}
+
+type shapeTreeXML struct {
+	Shapes []shapeXML `xml:"cSld>spTree>sp"`
+}
+
+type shapeXML struct {
+	Placeholder *struct {
+		Type string `xml:"type,attr"`
+	} `xml:"nvSpPr>nvPr>ph"`
+	Paragraphs []struct {
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:",any"`
+	} `xml:"txBody>p"`
+}
+
+// text liefert den Text des Shapes, Absätze werden durch Zeilenumbrüche getrennt.
+func (s shapeXML) text() string {
+	paragraphs := make([]string, 0, len(s.Paragraphs))
+	for _, p := range s.Paragraphs {
+		var b strings.Builder
+		for _, run := range p.Runs {
+			b.WriteString(run.Text)
+		}
+		paragraphs = append(paragraphs, b.String())
+	}
+	return strings.TrimSpace(strings.Join(paragraphs, "\n"))
+}
+
+func (s shapeXML) placeholderType() string {
+	if s.Placeholder == nil {
+		return ""
+	}
+	return s.Placeholder.Type
+}
+
+type pptxPackage struct {
+	reader *zip.ReadCloser
+	files  map[string]*zip.File
+}
+
+func (c *OOXMLParser) Parse(pptxPath string) (*domain.Deck, error) {
+	c.logger.WithField("pptx", pptxPath).Info("lese Präsentationsdaten aus PPTX")
+
+	pkg, err := openPackage(pptxPath)
+	if err != nil {
+		return nil, err
+	}
+	defer pkg.Close()
+
+	var presentation presentationXML
+	if err := pkg.decode("ppt/presentation.xml", &presentation); err != nil {
+		return nil, err
+	}
+
+	rels, err := pkg.relationships("ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	deck := &domain.Deck{}
+	for i, slideID := range presentation.SlideIDs {
+		rel, ok := rels[slideID.RID]
+		if !ok || rel.Type != relTypeSlide {
+			return nil, fmt.Errorf("%w: Slide-Referenz %s nicht auflösbar", domain.ErrInvalidFile, slideID.RID)
+		}
+
+		slidePart := resolvePartName("ppt/presentation.xml", rel.Target)
+		slide, err := pkg.parseSlide(slidePart)
+		if err != nil {
+			return nil, err
+		}
+		slide.Number = i + 1
+
+		if slide.Notes, err = pkg.parseNotes(slidePart); err != nil {
+			return nil, err
+		}
+
+		deck.Slides = append(deck.Slides, *slide)
+	}
+
+	c.logger.WithField("slideCount", len(deck.Slides)).Info("Präsentationsdaten gelesen")
+	return deck, nil
+}
+
+func (c *OOXMLParser) ExtractPart(pptxPath, part, destPath string) error {
+	pkg, err := openPackage(pptxPath)
+	if err != nil {
+		return err
+	}
+	defer pkg.Close()
+
+	src, err := pkg.open(part)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen der Zieldatei: %w", err)
+	}
+	defer dest.Close()
+
+	if _, err := io.Copy(dest, src); err != nil {
+		return fmt.Errorf("fehler beim Extrahieren von %s: %w", part, err)
+	}
+
+	return nil
+}
+
+func openPackage(pptxPath string) (*pptxPackage, error) {
+	reader, err := zip.OpenReader(pptxPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: PPTX kann nicht geöffnet werden: %v", domain.ErrInvalidFile, err)
+	}
+
+	pkg := &pptxPackage{
+		reader: reader,
+		files:  make(map[string]*zip.File, len(reader.File)),
+	}
+	for _, f := range reader.File {
+		pkg.files[f.Name] = f
+	}
+
+	return pkg, nil
+}
+
+func (p *pptxPackage) Close() error {
+	return p.reader.Close()
+}
+
+func (p *pptxPackage) open(part string) (io.ReadCloser, error) {
+	f, ok := p.files[part]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s fehlt in der PPTX", domain.ErrInvalidFile, part)
+	}
+	return f.Open()
+}
+
+func (p *pptxPackage) decode(part string, v interface{}) error {
+	r, err := p.open(part)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s kann nicht gelesen werden: %v", domain.ErrInvalidFile, part, err)
+	}
+	return nil
+}
+
+func (p *pptxPackage) relationships(part string) (map[string]relationshipXML, error) {
+	relsPart := path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+
+	rels := make(map[string]relationshipXML)
+	if _, ok := p.files[relsPart]; !ok {
+		return rels, nil
+	}
+
+	var parsed relationshipsXML
+	if err := p.decode(relsPart, &parsed); err != nil {
+		return nil, err
+	}
+
+	for _, rel := range parsed.Relationships {
+		rels[rel.ID] = rel
+	}
+	return rels, nil
+}
+
+func (p *pptxPackage) parseSlide(part string) (*domain.DeckSlide, error) {
+	r, err := p.open(part)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	slide := &domain.DeckSlide{}
+	decoder := xml.NewDecoder(r)
+
+	// Die Übergangsdaten können doppelt vorkommen: PowerPoint 2010+ schreibt
+	// neue Effekte in mc:Choice und ein klassisches Äquivalent in mc:Fallback.
+	// Der erste abbildbare Effekt gewinnt.
+	var inTransition, sawTransition, sawEffect bool
+	var mediaRefs []string
+	depth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s kann nicht gelesen werden: %v", domain.ErrInvalidFile, part, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case t.Name.Local == "sld" && depth == 1:
+				if show, ok := xmlAttr(t, "show"); ok {
+					slide.Hidden = show == "0" || show == "false"
+				}
+			case t.Name.Local == "media" || t.Name.Local == "audioFile":
+				// Aufgezeichnete Kommentare hängen als p14:media (eingebettet)
+				// bzw. a:audioFile (verknüpft) an einem Bild-Shape der Slide.
+				for _, attr := range t.Attr {
+					if attr.Name.Space == nsRelations && (attr.Name.Local == "embed" || attr.Name.Local == "link") {
+						mediaRefs = append(mediaRefs, attr.Value)
+					}
+				}
+			case t.Name.Local == "transition":
+				inTransition = true
+				sawTransition = true
+				if advTm, ok := xmlAttr(t, "advTm"); ok && slide.AdvanceAfter == 0 {
+					if ms, err := strconv.Atoi(advTm); err == nil && ms > 0 {
+						slide.AdvanceAfter = float64(ms) / 1000
+					}
+				}
+			case inTransition:
+				if t.Name.Local == "sndAc" || t.Name.Local == "extLst" {
+					decoder.Skip()
+					depth--
+					continue
+				}
+				sawEffect = true
+				if slide.Transition == "" {
+					slide.Transition = mapPPTXTransition(t)
+				}
+				decoder.Skip()
+				depth--
+			}
+		case xml.EndElement:
+			depth--
+			if t.Name.Local == "transition" {
+				inTransition = false
+			}
+		}
+	}
+
+	if slide.Transition == "" && sawEffect {
+		slide.Transition = domain.TransitionFade
+	}
+	if sawTransition && !sawEffect {
+		// <p:transition> ohne Effekt ist in PowerPoint ein harter Schnitt.
+		slide.Transition = domain.TransitionCut
+	}
+
+	if len(mediaRefs) > 0 {
+		narration, err := p.resolveNarration(part, mediaRefs)
+		if err != nil {
+			return nil, err
+		}
+		slide.Narration = narration
+	}
+
+	var tree shapeTreeXML
+	if err := p.decode(part, &tree); err != nil {
+		return nil, err
+	}
+	for _, shape := range tree.Shapes {
+		if ph := shape.placeholderType(); ph == "title" || ph == "ctrTitle" {
+			slide.Title = strings.Join(strings.Fields(shape.text()), " ")
+			break
+		}
+	}
+
+	return slide, nil
+}
+
+// parseNotes liefert den Text der Sprechernotizen einer Slide.
+func (p *pptxPackage) parseNotes(slidePart string) (string, error) {
+	rels, err := p.relationships(slidePart)
+	if err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels {
+		if rel.Type != relTypeNotesSlide {
+			continue
+		}
+
+		var notes shapeTreeXML
+		if err := p.decode(resolvePartName(slidePart, rel.Target), &notes); err != nil {
+			return "", err
+		}
+
+		var texts []string
+		for _, shape := range notes.Shapes {
+			if shape.placeholderType() == "body" {
+				if text := shape.text(); text != "" {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n"), nil
+	}
+
+	return "", nil
+}
+
+// resolveNarration liefert den Part der ersten eingebetteten Audiodatei.
+// Extern verknüpfte Dateien sind nicht Teil der PPTX und werden ignoriert.
+func (p *pptxPackage) resolveNarration(slidePart string, refs []string) (string, error) {
+	rels, err := p.relationships(slidePart)
+	if err != nil {
+		return "", err
+	}
+
+	for _, ref := range refs {
+		rel, ok := rels[ref]
+		if !ok || rel.TargetMode == "External" {
+			continue
+		}
+
+		part := resolvePartName(slidePart, rel.Target)
+		if _, exists := p.files[part]; !exists {
+			continue
+		}
+		if slices.Contains(narrationExtensions, strings.ToLower(path.Ext(part))) {
+			return part, nil
+		}
+	}
+
+	return "", nil
+}
+
+// mapPPTXTransition bildet einen PowerPoint-Übergang auf den ähnlichsten
+// xfade-Übergang ab. Unbekannte Effekte liefern "".
+func mapPPTXTransition(el xml.StartElement) string {
+	dir, _ := xmlAttr(el, "dir")
+	orient, _ := xmlAttr(el, "orient")
+	thruBlk, _ := xmlAttr(el, "thruBlk")
+	throughBlack := thruBlk == "1" || thruBlk == "true"
+
+	switch el.Name.Local {
+	case "cut":
+		if throughBlack {
+			return "fadeblack"
+		}
+		return domain.TransitionCut
+	case "fade":
+		if throughBlack {
+			return "fadeblack"
+		}
+		return domain.TransitionFade
+	case "dissolve", "randomBar", "glitter", "shred", "honeycomb", "ripple":
+		return "dissolve"
+	case "wipe":
+		return "wipe" + sideDirection(dir, "left")
+	case "push", "cover", "pull", "pan", "conveyor", "gallery", "ferris":
+		return "slide" + sideDirection(dir, "left")
+	case "split":
+		axis := "horz"
+		if orient == "vert" {
+			axis = "vert"
+		}
+		if dir == "in" {
+			return axis + "close"
+		}
+		return axis + "open"
+	case "doors", "window":
+		if dir == "horz" {
+			return "horzopen"
+		}
+		return "vertopen"
+	case "blinds":
+		if dir == "vert" {
+			return "vuslice"
+		}
+		return "hlslice"
+	case "strips":
+		switch dir {
+		case "ru":
+			return "diagtr"
+		case "ld":
+			return "diagbl"
+		case "rd":
+			return "diagbr"
+		default:
+			return "diagtl"
+		}
+	case "circle", "diamond", "plus":
+		return "circleopen"
+	case "wheel", "wedge", "vortex":
+		return "radial"
+	case "zoom", "warp", "flythrough":
+		return "zoomin"
+	case "checker":
+		return "pixelize"
+	case "flash":
+		return "fadewhite"
+	case "reveal":
+		return "fadeblack"
+	case "random", "newsflash", "prism", "switch", "flip", "cube", "morph", "prstTrans":
+		return domain.TransitionFade
+	}
+	return ""
+}
+
+func sideDirection(dir, fallback string) string {
+	switch {
+	case strings.HasPrefix(dir, "l"):
+		return "left"
+	case strings.HasPrefix(dir, "r"):
+		return "right"
+	case dir == "u":
+		return "up"
+	case dir == "d":
+		return "down"
+	}
+	return fallback
+}
+
+func xmlAttr(el xml.StartElement, name string) (string, bool) {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// resolvePartName löst ein Relationship-Target relativ zum Quell-Part auf.
+func resolvePartName(sourcePart, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(path.Dir(sourcePart), target)
+}
@@ -0,0 +1,202 @@
+package converter
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pptx2mp4/backend/internal/domain"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testSlideNamespaces = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" ` +
+		`xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" ` +
+		`xmlns:p14="http://schemas.microsoft.com/office/powerpoint/2010/main"`
+
+	testShapeTree = `<p:cSld><p:spTree>` +
+		`<p:sp><p:nvSpPr><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>` +
+		`<p:txBody><a:p><a:r><a:t>Titel der </a:t></a:r><a:r><a:t>Slide</a:t></a:r></a:p></p:txBody></p:sp>` +
+		`</p:spTree></p:cSld>`
+)
+
+func TestParseSlide(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs string
+		body  string
+		want  domain.DeckSlide
+	}{
+		{
+			name: "ohne übergang",
+			want: domain.DeckSlide{},
+		},
+		{
+			name: "zeitvorgabe und überblendung",
+			body: `<p:transition advTm="3500"><p:fade/></p:transition>`,
+			want: domain.DeckSlide{AdvanceAfter: 3.5, Transition: domain.TransitionFade},
+		},
+		{
+			name: "übergang ohne effekt ist ein harter schnitt",
+			body: `<p:transition advTm="2000"/>`,
+			want: domain.DeckSlide{AdvanceAfter: 2, Transition: domain.TransitionCut},
+		},
+		{
+			name: "wischen mit richtung",
+			body: `<p:transition><p:wipe dir="r"/></p:transition>`,
+			want: domain.DeckSlide{Transition: "wiperight"},
+		},
+		{
+			name: "schnitt über schwarz",
+			body: `<p:transition><p:cut thruBlk="1"/></p:transition>`,
+			want: domain.DeckSlide{Transition: "fadeblack"},
+		},
+		{
+			name: "klang wird ignoriert",
+			body: `<p:transition advTm="1000"><p:sndAc><p:stSnd><p:snd r:embed="rId9"/></p:stSnd></p:sndAc></p:transition>`,
+			want: domain.DeckSlide{AdvanceAfter: 1, Transition: domain.TransitionCut},
+		},
+		{
+			name: "neuer effekt hat vorrang vor dem fallback",
+			body: `<mc:AlternateContent><mc:Choice Requires="p14">` +
+				`<p:transition advTm="4000"><p14:vortex dir="r"/></p:transition>` +
+				`</mc:Choice><mc:Fallback>` +
+				`<p:transition advTm="5000"><p:fade/></p:transition>` +
+				`</mc:Fallback></mc:AlternateContent>`,
+			want: domain.DeckSlide{AdvanceAfter: 4, Transition: "radial"},
+		},
+		{
+			name: "unbekannter effekt fällt auf den fallback zurück",
+			body: `<mc:AlternateContent><mc:Choice Requires="p14">` +
+				`<p:transition><p14:unknownEffect/></p:transition>` +
+				`</mc:Choice><mc:Fallback>` +
+				`<p:transition><p:push dir="u"/></p:transition>` +
+				`</mc:Fallback></mc:AlternateContent>`,
+			want: domain.DeckSlide{Transition: "slideup"},
+		},
+		{
+			name:  "ausgeblendet",
+			attrs: ` show="0"`,
+			want:  domain.DeckSlide{Hidden: true},
+		},
+		{
+			name:  "ausdrücklich sichtbar",
+			attrs: ` show="1"`,
+			want:  domain.DeckSlide{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestPPTX(t, map[string]string{
+				"ppt/slides/slide1.xml": testSlide(tt.attrs, tt.body),
+			})
+			pkg, err := openPackage(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pkg.Close()
+
+			got, err := pkg.parseSlide("ppt/slides/slide1.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.want.Title = "Titel der Slide"
+			if *got != tt.want {
+				t.Errorf("parseSlide() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	path := writeTestPPTX(t, map[string]string{
+		"ppt/presentation.xml": `<p:presentation ` + testSlideNamespaces + `><p:sldIdLst>` +
+			`<p:sldId id="256" r:id="rId2"/><p:sldId id="257" r:id="rId3"/><p:sldId id="258" r:id="rId4"/>` +
+			`</p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": testRelationships(
+			`<Relationship Id="rId2" Type="`+relTypeSlide+`" Target="slides/slide1.xml"/>`,
+			`<Relationship Id="rId3" Type="`+relTypeSlide+`" Target="slides/slide2.xml"/>`,
+			`<Relationship Id="rId4" Type="`+relTypeSlide+`" Target="slides/slide3.xml"/>`,
+		),
+		"ppt/slides/slide1.xml": testSlide("", `<p:transition advTm="2500"/>`),
+		"ppt/slides/_rels/slide1.xml.rels": testRelationships(
+			`<Relationship Id="rId1" Type="` + relTypeNotesSlide + `" Target="../notesSlides/notesSlide1.xml"/>`,
+		),
+		"ppt/notesSlides/notesSlide1.xml": `<p:notes ` + testSlideNamespaces + `><p:cSld><p:spTree>` +
+			`<p:sp><p:nvSpPr><p:nvPr><p:ph type="body"/></p:nvPr></p:nvSpPr>` +
+			`<p:txBody><a:p><a:r><a:t>Erster Satz.</a:t></a:r></a:p><a:p><a:r><a:t>Zweiter Satz.</a:t></a:r></a:p></p:txBody></p:sp>` +
+			`</p:spTree></p:cSld></p:notes>`,
+		"ppt/slides/slide2.xml": testSlide(` show="0"`, ""),
+		"ppt/slides/slide3.xml": testSlide("", `<p:transition><p:fade/></p:transition>`),
+	})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	deck, err := NewOOXMLParser(logger).Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []domain.DeckSlide{
+		{Number: 1, Title: "Titel der Slide", AdvanceAfter: 2.5, Transition: domain.TransitionCut, Notes: "Erster Satz.\nZweiter Satz."},
+		{Number: 2, Title: "Titel der Slide", Hidden: true},
+		{Number: 3, Title: "Titel der Slide", Transition: domain.TransitionFade},
+	}
+	if len(deck.Slides) != len(want) {
+		t.Fatalf("Parse() lieferte %d Slides, want %d", len(deck.Slides), len(want))
+	}
+	for i := range want {
+		if deck.Slides[i] != want[i] {
+			t.Errorf("slide %d = %+v, want %+v", i+1, deck.Slides[i], want[i])
+		}
+	}
+
+	visible := deck.VisibleSlides()
+	if len(visible) != 2 || visible[0].Number != 1 || visible[1].Number != 3 {
+		t.Errorf("VisibleSlides() = %+v, want Slides 1 und 3", visible)
+	}
+}
+
+func testSlide(attrs, body string) string {
+	return `<p:sld ` + testSlideNamespaces + attrs + `>` + testShapeTree + body + `</p:sld>`
+}
+
+func testRelationships(rels ...string) string {
+	return `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		strings.Join(rels, "") + `</Relationships>`
+}
+
+func writeTestPPTX(t *testing.T, parts map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.pptx")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, content := range parts {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fmt.Fprint(w, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
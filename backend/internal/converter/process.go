@@ -0,0 +1,35 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// processWaitDelay begrenzt, wie lange nach dem Beenden eines Programms
+// noch auf dessen Ausgabe gewartet wird, falls Kindprozesse sie offen halten.
+const processWaitDelay = 5 * time.Second
+
+// commandContext erzeugt einen Befehl, der in einer eigenen Prozessgruppe
+// läuft. Wird ctx abgebrochen oder läuft ab, wird die ganze Gruppe beendet,
+// sodass keine Kindprozesse (z.B. soffice.bin) zurückbleiben.
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = processWaitDelay
+	return cmd
+}
+
+// commandFailed liefert den Fehler eines fehlgeschlagenen Programms. Wurde
+// es wegen ctx beendet, enthält der Fehler ctx.Err(), damit Aufrufer Abbruch
+// und Zeitüberschreitung erkennen können.
+func commandFailed(ctx context.Context, kind error, output []byte) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %w", kind, ctxErr)
+	}
+	return fmt.Errorf("%w: %s", kind, string(output))
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package converter
+
+import "os/exec"
+
+// Ohne Prozessgruppen wird nur der gestartete Prozess selbst beendet.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
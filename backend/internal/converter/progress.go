@@ -0,0 +1,122 @@
+package converter
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressFunc erhält den Fortschritt eines Schritts als Anteil von 0 bis 1.
+type ProgressFunc func(fraction float64)
+
+func (f ProgressFunc) report(fraction float64) {
+	if f == nil {
+		return
+	}
+	f(min(max(fraction, 0), 1))
+}
+
+// span bildet den Fortschritt eines Teilschritts auf den Bereich
+// start..start+width des gesamten Schritts ab.
+func (f ProgressFunc) span(start, width float64) ProgressFunc {
+	if f == nil {
+		return nil
+	}
+	return func(fraction float64) {
+		f.report(start + width*fraction)
+	}
+}
+
+// ofDuration liefert eine Funktion für runFFmpeg, die die kodierte Zeit in
+// Sekunden auf total bezieht.
+func (f ProgressFunc) ofDuration(total float64) func(seconds float64) {
+	if f == nil || total <= 0 {
+		return nil
+	}
+	return func(seconds float64) {
+		f.report(seconds / total)
+	}
+}
+
+// segmentProgress fasst den Fortschritt parallel kodierter Segmente zusammen.
+type segmentProgress struct {
+	mu       sync.Mutex
+	done     []float64
+	total    float64
+	progress ProgressFunc
+}
+
+func newSegmentProgress(segments []videoSegment, fps int, progress ProgressFunc) *segmentProgress {
+	total := 0.0
+	for _, segment := range segments {
+		total += float64(segment.frameCount()) / float64(fps)
+	}
+	return &segmentProgress{
+		done:     make([]float64, len(segments)),
+		total:    total,
+		progress: progress,
+	}
+}
+
+// segment liefert die Rückmeldung für runFFmpeg beim Kodieren von Segment n.
+func (p *segmentProgress) segment(n int) func(seconds float64) {
+	if p.progress == nil || p.total <= 0 {
+		return nil
+	}
+	return func(seconds float64) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.done[n] = seconds
+		sum := 0.0
+		for _, done := range p.done {
+			sum += done
+		}
+		p.progress.report(sum / p.total)
+	}
+}
+
+// parseFFmpegProgress liest die Ausgabe von -progress und meldet die bisher
+// kodierte Zeit in Sekunden. ffmpeg schreibt Blöcke aus key=value-Zeilen;
+// out_time_ms enthält trotz des Namens ebenfalls Mikrosekunden.
+func parseFFmpegProgress(r io.Reader, onTime func(seconds float64)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || (key != "out_time_us" && key != "out_time_ms") {
+			continue
+		}
+		micros, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || micros < 0 {
+			continue
+		}
+		onTime(float64(micros) / 1e6)
+	}
+}
+
+// watchPages zählt regelmäßig die bereits gerenderten Seiten in dir, bis
+// stop geschlossen wird.
+func watchPages(dir string, total int, progress ProgressFunc, stop <-chan struct{}) {
+	if progress == nil || total <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			pages, err := filepath.Glob(filepath.Join(dir, "slide-*.png"))
+			if err == nil {
+				progress.report(float64(len(pages)) / float64(total))
+			}
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"pptx2mp4/backend/internal/domain"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// videoSegment ist ein Abschnitt des Videos, der einzeln kodiert und danach
+// per Stream-Copy angefügt wird. Ohne transition ist es eine Folge stehender
+// Slides mit frames Bildern je Slide. Mit transition zeigt es den Übergang
+// von slides[0] nach slides[1], frames enthält dann nur dessen Länge.
+type videoSegment struct {
+	slides     []int
+	frames     []int
+	transition string
+}
+
+func (s videoSegment) frameCount() int {
+	count := 0
+	for _, frames := range s.frames {
+		count += frames
+	}
+	return count
+}
+
+// planSegments zerlegt die Timeline in Standbild- und Übergangssegmente.
+// Aufeinanderfolgende Slides mit hartem Schnitt landen im selben
+// Standbild-Segment, ein Deck ohne Übergänge also in einem einzigen.
+// Die Grenzen werden auf ganze Frames gerundet, ohne dass sich
+// Rundungsfehler über die Segmente aufsummieren.
+//
+// Liefert false, wenn der Weg über Segmente nicht möglich ist: bei zwei
+// Durchläufen, eingebrannten Untertiteln oder sich überlappenden Übergängen.
+func planSegments(request *EncodeRequest) ([]videoSegment, bool) {
+	config := request.Config
+	if config.Encoder.TwoPass {
+		return nil, false
+	}
+	if request.SubtitlesPath != "" && config.SubtitleMode == domain.SubtitleModeBurnIn {
+		return nil, false
+	}
+
+	timeline := request.Timeline
+	starts := timeline.StartTimes()
+	totalDuration := timeline.TotalDuration()
+	frameAt := func(t float64) int {
+		return int(math.Round(t * float64(config.FPS)))
+	}
+
+	var segments []videoSegment
+	still := videoSegment{}
+	flush := func() {
+		if len(still.slides) > 0 {
+			segments = append(segments, still)
+			still = videoSegment{}
+		}
+	}
+
+	for i, timing := range timeline {
+		holdStart := starts[i] + timing.TransitionDuration
+		if i > 0 && timing.TransitionDuration > 0 {
+			flush()
+			if frames := frameAt(holdStart) - frameAt(starts[i]); frames > 0 {
+				segments = append(segments, videoSegment{
+					slides:     []int{i - 1, i},
+					frames:     []int{frames},
+					transition: timing.Transition,
+				})
+			}
+		}
+
+		holdEnd := totalDuration
+		if i+1 < len(timeline) {
+			holdEnd = starts[i+1]
+		}
+		frames := frameAt(holdEnd) - frameAt(holdStart)
+		if frames < 0 {
+			return nil, false
+		}
+		if frames > 0 {
+			still.slides = append(still.slides, i)
+			still.frames = append(still.frames, frames)
+		}
+	}
+	flush()
+
+	return segments, len(segments) > 0
+}
+
+// splitSegments teilt lange Standbild-Segmente an Slide-Grenzen in Stücke
+// von etwa gleicher Länge, damit sie auf parts Prozesse verteilt werden
+// können. Übergänge bleiben eigene Segmente.
+func splitSegments(segments []videoSegment, parts int) []videoSegment {
+	if parts <= 1 {
+		return segments
+	}
+
+	totalFrames := 0
+	for _, segment := range segments {
+		totalFrames += segment.frameCount()
+	}
+	target := max((totalFrames+parts-1)/parts, 1)
+
+	var result []videoSegment
+	for _, segment := range segments {
+		if segment.transition != "" || len(segment.slides) == 1 {
+			result = append(result, segment)
+			continue
+		}
+
+		chunk := videoSegment{}
+		for i, slide := range segment.slides {
+			chunk.slides = append(chunk.slides, slide)
+			chunk.frames = append(chunk.frames, segment.frames[i])
+			if chunk.frameCount() >= target && i < len(segment.slides)-1 {
+				result = append(result, chunk)
+				chunk = videoSegment{}
+			}
+		}
+		if len(chunk.slides) > 0 {
+			result = append(result, chunk)
+		}
+	}
+
+	return result
+}
+
+// encodeStillImages kodiert die Segmente in bis zu parallelism gleichzeitig
+// laufenden ffmpeg-Prozessen und fügt sie anschließend ohne erneutes
+// Kodieren zusammen. Audio, Untertitelspur und Kapitel werden erst dabei
+// ergänzt.
+func (e *FFmpegEncoder) encodeStillImages(ctx context.Context, request *EncodeRequest, images []string, segments []videoSegment) error {
+	segmentDir := filepath.Join(request.ImagesDir, "segments")
+	if err := os.MkdirAll(segmentDir, 0755); err != nil {
+		return fmt.Errorf("fehler beim Erstellen des Segment-Verzeichnisses: %w", err)
+	}
+
+	segments = splitSegments(segments, e.parallelism)
+	workers := min(e.parallelism, len(segments))
+
+	e.logger.WithFields(logrus.Fields{
+		"outputPath":   request.OutputPath,
+		"segmentCount": len(segments),
+		"workers":      workers,
+	}).Info("verwende Standbild-Encoding")
+
+	var list strings.Builder
+	segmentPaths := make([]string, len(segments))
+	for n := range segments {
+		segmentPaths[n] = filepath.Join(segmentDir, fmt.Sprintf("segment-%04d.mkv", n))
+		entry, err := concatEntry(segmentPaths[n])
+		if err != nil {
+			return err
+		}
+		list.WriteString(entry)
+	}
+
+	// Das Zusammenfügen per Stream-Copy ist im Vergleich kurz.
+	progress := newSegmentProgress(segments, request.Config.FPS, request.Progress.span(0, 0.95))
+	if err := e.renderSegments(ctx, request, images, segments, segmentPaths, workers, progress); err != nil {
+		return err
+	}
+
+	listPath := filepath.Join(segmentDir, "segments.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("fehler beim Schreiben der Segmentliste: %w", err)
+	}
+
+	args := []string{"-y", "-f", "concat", "-safe", "0", "-i", listPath}
+	args = appendOutputArgs(request, args, nil, "0:v", []string{"-c:v", "copy"})
+	if err := e.runFFmpeg(ctx, args, request.Progress.span(0.95, 0.05).ofDuration(request.Timeline.TotalDuration())); err != nil {
+		return err
+	}
+
+	e.logger.WithField("output", request.OutputPath).Info("video-encoding erfolgreich")
+	return nil
+}
+
+// renderSegments verteilt die Segmente auf workers Goroutinen. Nach dem
+// ersten Fehler werden keine weiteren Segmente gestartet und laufende
+// abgebrochen. Laufen mehrere Prozesse gleichzeitig, teilen sie sich die
+// CPU-Kerne.
+func (e *FFmpegEncoder) renderSegments(ctx context.Context, request *EncodeRequest, images []string, segments []videoSegment, segmentPaths []string, workers int, progress *segmentProgress) error {
+	threads := 0
+	if workers > 1 {
+		threads = max(runtime.NumCPU()/workers, 1)
+	}
+
+	renderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	indices := make(chan int)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range indices {
+				mu.Lock()
+				failed := firstErr != nil
+				mu.Unlock()
+				if failed {
+					continue
+				}
+
+				if err := e.renderSegment(renderCtx, request, images, segments[n], segmentPaths[n], threads, progress.segment(n)); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("segment %d: %w", n, err)
+						cancel()
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for n := range segments {
+		indices <- n
+	}
+	close(indices)
+	wg.Wait()
+
+	return firstErr
+}
+
+// renderSegment kodiert ein einzelnes Segment; threads begrenzt die
+// Encoder-Threads, 0 überlässt die Wahl ffmpeg. Standbilder werden über den
+// concat-Demuxer mit ihrer Anzeigedauer eingelesen, sodass jede Slide nur
+// einmal dekodiert und skaliert wird.
+func (e *FFmpegEncoder) renderSegment(ctx context.Context, request *EncodeRequest, images []string, segment videoSegment, outputPath string, threads int, onTime func(seconds float64)) error {
+	config := request.Config
+	fps := float64(config.FPS)
+
+	var args, filterParts []string
+	if segment.transition != "" {
+		for input, slide := range segment.slides {
+			args = append(args, "-loop", "1", "-framerate", fmt.Sprintf("%d", config.FPS), "-i", images[slide])
+			filterParts = append(filterParts,
+				frameFilter(config, fmt.Sprintf("%d:v", input), fmt.Sprintf("f%d", input)),
+				fmt.Sprintf("[f%d]fps=%d,format=yuv420p[s%d]", input, config.FPS, input))
+		}
+		filterParts = append(filterParts,
+			fmt.Sprintf("[s0][s1]xfade=transition=%s:duration=%.4f:offset=0[v]",
+				segment.transition, float64(segment.frameCount())/fps))
+	} else {
+		var list strings.Builder
+		var entry string
+		for i, slide := range segment.slides {
+			var err error
+			if entry, err = concatEntry(images[slide]); err != nil {
+				return err
+			}
+			list.WriteString(entry)
+			fmt.Fprintf(&list, "duration %.6f\n", float64(segment.frames[i])/fps)
+		}
+		// Der concat-Demuxer ignoriert die Dauer des letzten Eintrags,
+		// daher wird das letzte Bild wiederholt.
+		list.WriteString(entry)
+
+		listPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".txt"
+		if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+			return fmt.Errorf("fehler beim Schreiben der Bildliste: %w", err)
+		}
+
+		args = append(args, "-f", "concat", "-safe", "0", "-i", listPath)
+		filterParts = append(filterParts,
+			frameFilter(config, "0:v", "f0"),
+			fmt.Sprintf("[f0]fps=%d,format=yuv420p[v]", config.FPS))
+	}
+
+	args = append([]string{"-y"}, args...)
+	args = append(args, "-filter_complex", strings.Join(filterParts, ";"), "-map", "[v]")
+	// Stehende Slides sparen mit dem Standbild-Tuning bei gleicher Qualität
+	// deutlich Bitrate; Übergänge sind Bewegung und werden normal kodiert.
+	codecs := formatCodecs[config.Format()]
+	tune := ""
+	if segment.transition == "" {
+		tune = "stillimage"
+	}
+	args = append(args, videoCodecArgs(codecs, config.Encoder, tune, 0, "")...)
+	if codecs.videoCodec == "libx264" {
+		// Das Tuning ändert die Parameter-Sets. Beim Zusammenfügen per
+		// Stream-Copy gelten sonst für alle Segmente die des ersten, daher
+		// trägt jedes Segment seine eigenen im Datenstrom.
+		args = append(args, "-x264-params", "repeat-headers=1")
+	}
+	if threads > 0 {
+		args = append(args, "-threads", fmt.Sprintf("%d", threads))
+	}
+	args = append(args, "-frames:v", fmt.Sprintf("%d", segment.frameCount()), "-an", outputPath)
+
+	return e.runFFmpeg(ctx, args, onTime)
+}
+
+// concatEntry liefert eine file-Zeile für den concat-Demuxer. Relative Pfade
+// würden relativ zur Listendatei aufgelöst, daher wird der absolute Pfad
+// verwendet.
+func concatEntry(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("fehler beim Auflösen von %s: %w", path, err)
+	}
+	return fmt.Sprintf("file '%s'\n", strings.ReplaceAll(absPath, "'", `'\''`)), nil
+}
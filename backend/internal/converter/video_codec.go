@@ -0,0 +1,67 @@
+package converter
+
+import (
+	"fmt"
+	"pptx2mp4/backend/internal/domain"
+)
+
+// defaultVP9CRF ist die Qualität für VP9, wenn weder CRF noch Bitrate
+// gesetzt sind. libvpx hat sonst keinen Modus mit konstanter Qualität.
+const defaultVP9CRF = 32
+
+// videoCodecArgs übersetzt die Encoder-Einstellungen in ffmpeg-Optionen für
+// den Video-Codec des Ausgabeformats. tune wählt ein x264-Tuning und wird
+// bei anderen Codecs ignoriert. pass ist 1 oder 2 bei zwei Durchläufen,
+// sonst 0; passLog ist der Präfix der Statistikdatei.
+func videoCodecArgs(codecs outputCodecs, settings domain.EncoderSettings, tune string, pass int, passLog string) []string {
+	args := []string{"-c:v", codecs.videoCodec}
+
+	switch {
+	case settings.VideoBitrate > 0:
+		args = append(args, "-b:v", fmt.Sprintf("%dk", settings.VideoBitrate))
+	case codecs.videoCodec == "libvpx-vp9":
+		crf := settings.CRF
+		if crf == 0 {
+			crf = defaultVP9CRF
+		}
+		args = append(args, "-crf", fmt.Sprintf("%d", crf), "-b:v", "0")
+	case settings.CRF > 0:
+		args = append(args, "-crf", fmt.Sprintf("%d", settings.CRF))
+	}
+
+	// libvpx kennt keine x264-Presets.
+	if settings.Preset != "" && codecs.videoCodec != "libvpx-vp9" {
+		args = append(args, "-preset", settings.Preset)
+	}
+
+	if settings.MaxRate > 0 {
+		args = append(args, "-maxrate", fmt.Sprintf("%dk", settings.MaxRate))
+	}
+	if settings.BufSize > 0 {
+		args = append(args, "-bufsize", fmt.Sprintf("%dk", settings.BufSize))
+	}
+
+	if codecs.videoCodec == "libx264" {
+		if settings.Profile != "" {
+			args = append(args, "-profile:v", settings.Profile)
+		}
+		if settings.Level != "" {
+			args = append(args, "-level:v", settings.Level)
+		}
+		if tune != "" {
+			args = append(args, "-tune", tune)
+		}
+	}
+
+	if pass > 0 {
+		if codecs.videoCodec == "libx265" {
+			// libx265 ignoriert -pass und erwartet die Angaben in x265-params.
+			args = append(args, "-x265-params", fmt.Sprintf("pass=%d:stats=%s.log", pass, passLog))
+		} else {
+			args = append(args, "-pass", fmt.Sprintf("%d", pass), "-passlogfile", passLog)
+		}
+	}
+
+	args = append(args, codecs.videoExtra...)
+	return append(args, "-pix_fmt", "yuv420p")
+}
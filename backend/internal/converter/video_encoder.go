@@ -1,10 +1,14 @@
 package converter
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"pptx2mp4/backend/internal/domain"
+	"slices"
 	"sort"
 	"strings"
 
@@ -12,88 +16,379 @@ import (
 )
 
 type VideoEncoder interface {
-	EncodeToMP4(imagesDir, outputPath string, config *domain.ConversionConfig) error
+	Encode(ctx context.Context, request *EncodeRequest) error
+}
+
+type EncodeRequest struct {
+	ImagesDir  string
+	OutputPath string
+	Config     *domain.ConversionConfig
+	Timeline   domain.Timeline
+	// BackgroundAudio ist optional und wird auf die Videolänge gekürzt
+	// bzw. in Schleife wiederholt.
+	BackgroundAudio string
+	// SubtitlesPath ist eine SRT-Datei, die je nach SubtitleMode als
+	// Untertitelspur eingebettet oder ins Bild gerendert wird.
+	SubtitlesPath string
+	// ChaptersPath ist eine ffmetadata-Datei mit Kapitelmarken.
+	ChaptersPath string
+	// Progress ist optional und erhält den Fortschritt des Encodings.
+	Progress ProgressFunc
+}
+
+// outputCodecs beschreibt die Encoder-Einstellungen eines Ausgabeformats.
+type outputCodecs struct {
+	videoCodec string
+	videoExtra []string
+	// videoTag setzt die Codec-Kennung im Container, z.B. hvc1 für HEVC,
+	// damit Apple-Player die Datei abspielen.
+	videoTag string
+	audio    []string
+	subtitle string
+	// fastStart gibt an, ob der Container -movflags +faststart unterstützt.
+	fastStart bool
+}
+
+var formatCodecs = map[string]outputCodecs{
+	domain.OutputFormatMP4: {
+		videoCodec: "libx264",
+		audio:      []string{"-c:a", "aac", "-b:a", "192k"},
+		subtitle:   "mov_text",
+		fastStart:  true,
+	},
+	domain.OutputFormatHEVC: {
+		videoCodec: "libx265",
+		videoTag:   "hvc1",
+		audio:      []string{"-c:a", "aac", "-b:a", "192k"},
+		subtitle:   "mov_text",
+		fastStart:  true,
+	},
+	domain.OutputFormatWebM: {
+		videoCodec: "libvpx-vp9",
+		videoExtra: []string{"-row-mt", "1"},
+		audio:      []string{"-c:a", "libopus", "-b:a", "128k"},
+		subtitle:   "webvtt",
+	},
+	domain.OutputFormatMOV: {
+		videoCodec: "libx264",
+		audio:      []string{"-c:a", "aac", "-b:a", "192k"},
+		subtitle:   "mov_text",
+		fastStart:  true,
+	},
 }
 
 type FFmpegEncoder struct {
-	logger *logrus.Logger
+	// parallelism begrenzt die Zahl gleichzeitig laufender ffmpeg-Prozesse
+	// beim Kodieren der Segmente eines Jobs.
+	parallelism int
+	logger      *logrus.Logger
 }
 
-func NewFFmpegEncoder(logger *logrus.Logger) *FFmpegEncoder {
+func NewFFmpegEncoder(parallelism int, logger *logrus.Logger) *FFmpegEncoder {
 	return &FFmpegEncoder{
-		logger: logger,
+		parallelism: max(parallelism, 1),
+		logger:      logger,
 	}
 }
 
-func (e *FFmpegEncoder) EncodeToMP4(imagesDir, outputPath string, config *domain.ConversionConfig) error {
+func (e *FFmpegEncoder) Encode(ctx context.Context, request *EncodeRequest) error {
+	config := request.Config
+	timeline := request.Timeline
+
 	e.logger.WithFields(logrus.Fields{
-		"imagesDir":          imagesDir,
-		"outputPath":         outputPath,
-		"fps":                config.FPS,
-		"duration":           config.Duration,
-		"transitionDuration": config.TransitionDuration,
+		"imagesDir":       request.ImagesDir,
+		"outputPath":      request.OutputPath,
+		"format":          config.Format(),
+		"fps":             config.FPS,
+		"slideCount":      len(timeline),
+		"totalDuration":   timeline.TotalDuration(),
+		"backgroundAudio": request.BackgroundAudio,
 	}).Info("starte Video-Encoding")
 
+	images, err := findSlideImages(request.ImagesDir)
+	if err != nil {
+		return err
+	}
+
+	if len(timeline) != len(images) {
+		return fmt.Errorf("%w: %d Slide-Bilder, aber %d Timeline-Einträge", domain.ErrVideoEncoding, len(images), len(timeline))
+	}
+
+	if config.Format() == domain.OutputFormatGIF {
+		args, filterParts, videoLabel := buildVideoInputs(request, images)
+		return e.encodeGIF(ctx, request, args, filterParts, videoLabel)
+	}
+
+	if segments, ok := planSegments(request); ok {
+		return e.encodeStillImages(ctx, request, images, segments)
+	}
+
+	args, filterParts, videoLabel := buildVideoInputs(request, images)
+	codecs := formatCodecs[config.Format()]
+	passLog := filepath.Join(request.ImagesDir, "ffmpeg2pass")
+
+	if config.Encoder.TwoPass {
+		firstPass := append(slices.Clone(args),
+			"-filter_complex", strings.Join(filterParts, ";"),
+			"-map", fmt.Sprintf("[%s]", videoLabel))
+		firstPass = append(firstPass, videoCodecArgs(codecs, config.Encoder, "", 1, passLog)...)
+		firstPass = append(firstPass, "-an", "-t", fmt.Sprintf("%.4f", timeline.TotalDuration()), "-f", "null", os.DevNull)
+		if err := e.runFFmpeg(ctx, firstPass, request.Progress.span(0, 0.5).ofDuration(timeline.TotalDuration())); err != nil {
+			return err
+		}
+	}
+
+	pass := 0
+	progress := request.Progress
+	if config.Encoder.TwoPass {
+		pass = 2
+		progress = progress.span(0.5, 0.5)
+	}
+	args = appendOutputArgs(request, args, filterParts, fmt.Sprintf("[%s]", videoLabel),
+		videoCodecArgs(codecs, config.Encoder, "", pass, passLog))
+
+	if err := e.runFFmpeg(ctx, args, progress.ofDuration(timeline.TotalDuration())); err != nil {
+		return err
+	}
+
+	e.logger.WithField("output", request.OutputPath).Info("video-encoding erfolgreich")
+	return nil
+}
+
+// appendOutputArgs ergänzt Audio, Untertitelspur und Kapitel und schließt
+// die Argumentliste mit den Ausgabeoptionen ab. videoMap ist das Label oder
+// der Stream des fertigen Videos, videoArgs enthält dessen Codec-Optionen.
+func appendOutputArgs(request *EncodeRequest, args, filterParts []string, videoMap string, videoArgs []string) []string {
+	config := request.Config
+	codecs := formatCodecs[config.Format()]
+	inputCount := countInputs(args)
+
+	audioArgs, audioFilter, audioLabel := buildAudioFilter(request, inputCount)
+	args = append(args, audioArgs...)
+	filterParts = append(filterParts, audioFilter...)
+
+	softSubtitles := request.SubtitlesPath != "" && config.SubtitleMode == domain.SubtitleModeSoft
+	subtitleInput := inputCount + countInputs(audioArgs)
+	if softSubtitles {
+		args = append(args, "-i", request.SubtitlesPath)
+	}
+
+	chaptersInput := subtitleInput
+	if softSubtitles {
+		chaptersInput++
+	}
+	if request.ChaptersPath != "" {
+		args = append(args, "-f", "ffmetadata", "-i", request.ChaptersPath)
+	}
+
+	if len(filterParts) > 0 {
+		args = append(args, "-filter_complex", strings.Join(filterParts, ";"))
+	}
+	args = append(args, "-map", videoMap)
+	if audioLabel != "" {
+		args = append(args, "-map", fmt.Sprintf("[%s]", audioLabel))
+		args = append(args, codecs.audio...)
+	}
+	if softSubtitles {
+		args = append(args, "-map", fmt.Sprintf("%d:s", subtitleInput), "-c:s", codecs.subtitle)
+	}
+	if request.ChaptersPath != "" {
+		args = append(args, "-map_chapters", fmt.Sprintf("%d", chaptersInput))
+	}
+	args = append(args, videoArgs...)
+	if codecs.videoTag != "" {
+		args = append(args, "-tag:v", codecs.videoTag)
+	}
+	if codecs.fastStart && config.Encoder.FastStart {
+		args = append(args, "-movflags", "+faststart")
+	}
+	return append(args, "-t", fmt.Sprintf("%.4f", request.Timeline.TotalDuration()), request.OutputPath)
+}
+
+// encodeGIF erzeugt ein animiertes GIF in zwei Durchläufen: zuerst wird
+// aus dem gesamten Video eine optimale Palette berechnet, danach wird das
+// Video mit dieser Palette kodiert. Audio, Untertitelspuren und Kapitel
+// entfallen.
+func (e *FFmpegEncoder) encodeGIF(ctx context.Context, request *EncodeRequest, inputArgs, filterParts []string, videoLabel string) error {
+	if request.BackgroundAudio != "" || request.ChaptersPath != "" {
+		e.logger.WithField("output", request.OutputPath).Warn("GIF unterstützt weder Audio noch Kapitel, diese werden ignoriert")
+	}
+
+	totalDuration := fmt.Sprintf("%.4f", request.Timeline.TotalDuration())
+	palettePath := filepath.Join(request.ImagesDir, "palette.png")
+
+	paletteArgs := append(slices.Clone(inputArgs),
+		"-filter_complex", strings.Join(append(slices.Clone(filterParts),
+			fmt.Sprintf("[%s]trim=duration=%s,palettegen=stats_mode=diff[palette]", videoLabel, totalDuration)), ";"),
+		"-map", "[palette]", "-update", "1", "-frames:v", "1", palettePath)
+	// Die Palette entsteht erst am Ende, dieser Durchlauf meldet daher
+	// keinen Fortschritt.
+	if err := e.runFFmpeg(ctx, paletteArgs, nil); err != nil {
+		return err
+	}
+
+	paletteInput := countInputs(inputArgs)
+	gifArgs := append(slices.Clone(inputArgs), "-i", palettePath,
+		"-filter_complex", strings.Join(append(slices.Clone(filterParts),
+			fmt.Sprintf("[%s][%d:v]paletteuse=dither=bayer:bayer_scale=5:diff_mode=rectangle[gif]", videoLabel, paletteInput)), ";"),
+		"-map", "[gif]", "-loop", "0", "-t", totalDuration, request.OutputPath)
+	if err := e.runFFmpeg(ctx, gifArgs, request.Progress.span(0.5, 0.5).ofDuration(request.Timeline.TotalDuration())); err != nil {
+		return err
+	}
+
+	e.logger.WithField("output", request.OutputPath).Info("GIF-Encoding erfolgreich")
+	return nil
+}
+
+// buildVideoInputs liefert die Bild-Eingaben und den Video-Filtergraphen
+// inklusive eingebrannter Untertitel.
+func buildVideoInputs(request *EncodeRequest, images []string) ([]string, []string, string) {
+	timeline := request.Timeline
+
+	args := []string{"-y"}
+	for i, img := range images {
+		args = append(args, "-loop", "1", "-t", fmt.Sprintf("%.4f", timeline[i].Duration), "-i", img)
+	}
+
+	filterParts, videoLabel := buildVideoFilter(request.Config, timeline)
+
+	if request.SubtitlesPath != "" && request.Config.SubtitleMode == domain.SubtitleModeBurnIn {
+		filterParts = append(filterParts,
+			fmt.Sprintf("[%s]subtitles=filename='%s'[vsub]", videoLabel, escapeFilterValue(request.SubtitlesPath)))
+		videoLabel = "vsub"
+	}
+
+	return args, filterParts, videoLabel
+}
+
+// runFFmpeg führt ffmpeg aus. Ist onTime gesetzt, meldet ffmpeg über
+// -progress laufend die bisher kodierte Zeit in Sekunden.
+func (e *FFmpegEncoder) runFFmpeg(ctx context.Context, args []string, onTime func(seconds float64)) error {
+	if onTime == nil {
+		cmd := commandContext(ctx, "ffmpeg", args...)
+		output, err := cmd.CombinedOutput()
+		return e.ffmpegResult(ctx, err, output)
+	}
+
+	cmd := commandContext(ctx, "ffmpeg", append([]string{"-progress", "pipe:1", "-nostats"}, args...)...)
+	var output bytes.Buffer
+	cmd.Stderr = &output
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrVideoEncoding, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return e.ffmpegResult(ctx, err, nil)
+	}
+	parseFFmpegProgress(stdout, onTime)
+	return e.ffmpegResult(ctx, cmd.Wait(), output.Bytes())
+}
+
+func (e *FFmpegEncoder) ffmpegResult(ctx context.Context, err error, output []byte) error {
+	if err != nil {
+		e.logger.WithError(err).WithField("output", string(output)).Error("video-encoding fehlgeschlagen")
+		return commandFailed(ctx, domain.ErrVideoEncoding, output)
+	}
+	return nil
+}
+
+// frameFilter passt eine Slide gemäß FitMode in das Ausgabebild ein und
+// liefert den Filtergraphen von Label input nach Label output.
+func frameFilter(config *domain.ConversionConfig, input, output string) string {
+	width, height := config.FrameSize()
+
+	switch config.FitMode {
+	case domain.FitModeStretch:
+		return fmt.Sprintf("[%s]scale=%d:%d,setsar=1[%s]", input, width, height, output)
+	case domain.FitModeCrop:
+		return fmt.Sprintf("[%s]scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,setsar=1[%s]",
+			input, width, height, width, height, output)
+	case domain.FitModeBlur:
+		return fmt.Sprintf("[%[1]s]split=2[%[2]sbg][%[2]sfg];"+
+			"[%[2]sbg]scale=%[3]d:%[4]d:force_original_aspect_ratio=increase,crop=%[3]d:%[4]d,boxblur=%[5]d:2[%[2]sblur];"+
+			"[%[2]sfg]scale=%[3]d:%[4]d:force_original_aspect_ratio=decrease[%[2]sfit];"+
+			"[%[2]sblur][%[2]sfit]overlay=(W-w)/2:(H-h)/2,setsar=1[%[2]s]",
+			input, output, width, height, blurRadius(width, height))
+	}
+
+	color := config.BackgroundColor
+	if color == "" {
+		color = domain.DefaultBackgroundColor
+	}
+	return fmt.Sprintf("[%s]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=0x%s,setsar=1[%s]",
+		input, width, height, width, height, strings.TrimPrefix(color, "#"), output)
+}
+
+// blurRadius skaliert die Unschärfe des Hintergrunds mit der Bildgröße,
+// damit sie bei 720p und 2160p gleich wirkt.
+func blurRadius(width, height int) int {
+	radius := min(width, height) / 40
+	return max(radius, 4)
+}
+
+// countInputs zählt die Eingabedateien in einer ffmpeg-Argumentliste.
+func countInputs(args []string) int {
+	count := 0
+	for _, arg := range args {
+		if arg == "-i" {
+			count++
+		}
+	}
+	return count
+}
+
+// escapeFilterValue maskiert einen Wert für die Verwendung in einem
+// einfach quotierten Filter-Argument.
+func escapeFilterValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `'\''`, `:`, `\:`).Replace(value)
+}
+
+func findSlideImages(imagesDir string) ([]string, error) {
 	images, err := filepath.Glob(filepath.Join(imagesDir, "slide-*.png"))
 	if err != nil || len(images) == 0 {
-		return fmt.Errorf("%w: keine Slide-Bilder gefunden in %s", domain.ErrVideoEncoding, imagesDir)
+		return nil, fmt.Errorf("%w: keine Slide-Bilder gefunden in %s", domain.ErrVideoEncoding, imagesDir)
 	}
 
+	sortSlideImages(images)
+	return images, nil
+}
+
+// sortSlideImages sortiert Slide-Bilder nach ihrer Seitennummer.
+func sortSlideImages(images []string) {
 	sort.Slice(images, func(i, j int) bool {
 		var ni, nj int
 		fmt.Sscanf(filepath.Base(images[i]), "slide-%d.png", &ni)
 		fmt.Sscanf(filepath.Base(images[j]), "slide-%d.png", &nj)
 		return ni < nj
 	})
+}
 
-	N := len(images)
-	D := float64(config.Duration)
-	T := config.TransitionDuration
-
-	args := []string{"-y"}
-	for _, img := range images {
-		args = append(args, "-loop", "1", "-t", fmt.Sprintf("%.4f", D), "-i", img)
-	}
-
+// buildVideoFilter skaliert alle Slides und verkettet sie gemäß Timeline
+// mit xfade-Übergängen bzw. harten Schnitten. Liefert das Label des Ergebnisses.
+func buildVideoFilter(config *domain.ConversionConfig, timeline domain.Timeline) ([]string, string) {
 	var filterParts []string
-	for i := range images {
+	for i := range timeline {
 		filterParts = append(filterParts,
-			fmt.Sprintf("[%d:v]scale=-2:%d,fps=%d,format=yuv420p[v%d]", i, config.Resolution, config.FPS, i))
+			frameFilter(config, fmt.Sprintf("%d:v", i), fmt.Sprintf("f%d", i)),
+			fmt.Sprintf("[f%d]fps=%d,format=yuv420p[v%d]", i, config.FPS, i))
 	}
 
+	starts := timeline.StartTimes()
 	lastLabel := "v0"
-	if N > 1 && T > 0 {
-		for i := 1; i < N; i++ {
-			offset := float64(i) * (D - T)
-			next := fmt.Sprintf("x%d", i)
+	for i := 1; i < len(timeline); i++ {
+		next := fmt.Sprintf("x%d", i)
+		if timeline[i].TransitionDuration > 0 {
 			filterParts = append(filterParts,
-				fmt.Sprintf("[%s][v%d]xfade=transition=fade:duration=%.4f:offset=%.4f[%s]",
-					lastLabel, i, T, offset, next))
-			lastLabel = next
-		}
-	} else if N > 1 {
-		var concatInputs string
-		for i := range images {
-			concatInputs += fmt.Sprintf("[v%d]", i)
+				fmt.Sprintf("[%s][v%d]xfade=transition=%s:duration=%.4f:offset=%.4f[%s]",
+					lastLabel, i, timeline[i].Transition, timeline[i].TransitionDuration, starts[i], next))
+		} else {
+			filterParts = append(filterParts,
+				fmt.Sprintf("[%s][v%d]concat=n=2:v=1:a=0[%s]", lastLabel, i, next))
 		}
-		filterParts = append(filterParts,
-			fmt.Sprintf("%sconcat=n=%d:v=1:a=0[out]", concatInputs, N))
-		lastLabel = "out"
+		lastLabel = next
 	}
 
-	args = append(args, "-filter_complex", strings.Join(filterParts, ";"))
-	args = append(args, "-map", fmt.Sprintf("[%s]", lastLabel))
-	args = append(args, "-c:v", "libx264", "-pix_fmt", "yuv420p", outputPath)
-
-	cmd := exec.Command("ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		e.logger.WithError(err).WithField("output", string(output)).Error("video-encoding fehlgeschlagen")
-		return fmt.Errorf("%w: %s", domain.ErrVideoEncoding, string(output))
-	}
-
-	e.logger.WithField("output", outputPath).Info("video-encoding erfolgreich")
-	return nil
+	return filterParts, lastLabel
 }
 
 func (e *FFmpegEncoder) IsAvailable() bool {
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Chapter struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Title string  `json:"title"`
+}
+
+// BuildChapters erzeugt ein Kapitel je Slide mit Titel. Slides ohne Titel
+// oder mit dem Titel der vorherigen Slide setzen das laufende Kapitel fort.
+// titles ist nach der Position der Slide im Video (0-basiert) indiziert.
+func BuildChapters(timeline Timeline, titles []string) []Chapter {
+	starts := timeline.StartTimes()
+
+	var chapters []Chapter
+	for i := range timeline {
+		title := ""
+		if i < len(titles) {
+			title = titles[i]
+		}
+
+		if len(chapters) == 0 {
+			if title == "" {
+				title = fmt.Sprintf("Folie %d", i+1)
+			}
+			chapters = append(chapters, Chapter{Start: 0, Title: title})
+			continue
+		}
+
+		if title == "" || title == chapters[len(chapters)-1].Title {
+			continue
+		}
+
+		chapters[len(chapters)-1].End = starts[i]
+		chapters = append(chapters, Chapter{Start: starts[i], Title: title})
+	}
+
+	if len(chapters) > 0 {
+		chapters[len(chapters)-1].End = timeline.TotalDuration()
+	}
+
+	return chapters
+}
+
+// FormatFFMetadata liefert die Kapitel im ffmetadata-Format für ffmpeg.
+func FormatFFMetadata(chapters []Chapter) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(chapter.Start*1000), int64(chapter.End*1000), escaper.Replace(chapter.Title))
+	}
+	return b.String()
+}
+
+// FormatYouTubeChapters liefert die Kapitel als Zeitstempel-Liste für eine
+// YouTube-Videobeschreibung.
+func FormatYouTubeChapters(chapters []Chapter) string {
+	withHours := len(chapters) > 0 && chapters[len(chapters)-1].Start >= 3600
+
+	var b strings.Builder
+	for _, chapter := range chapters {
+		seconds := int64(chapter.Start)
+		if withHours {
+			fmt.Fprintf(&b, "%d:%02d:%02d %s\n", seconds/3600, seconds/60%60, seconds%60, chapter.Title)
+		} else {
+			fmt.Fprintf(&b, "%02d:%02d %s\n", seconds/60, seconds%60, chapter.Title)
+		}
+	}
+	return b.String()
+}
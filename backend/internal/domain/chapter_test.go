@@ -0,0 +1,111 @@
+package domain
+
+import "testing"
+
+func TestBuildChapters(t *testing.T) {
+	timeline := Timeline{
+		{Duration: 5},
+		{Duration: 5, Transition: TransitionFade, TransitionDuration: 1},
+		{Duration: 5, Transition: TransitionFade, TransitionDuration: 1},
+		{Duration: 5, Transition: TransitionCut},
+	}
+
+	tests := []struct {
+		name   string
+		titles []string
+		want   []Chapter
+	}{
+		{
+			name:   "ein kapitel je titel",
+			titles: []string{"Intro", "Agenda", "Details", "Ende"},
+			want: []Chapter{
+				{Start: 0, End: 4, Title: "Intro"},
+				{Start: 4, End: 8, Title: "Agenda"},
+				{Start: 8, End: 13, Title: "Details"},
+				{Start: 13, End: 18, Title: "Ende"},
+			},
+		},
+		{
+			name:   "slides ohne titel und wiederholte titel setzen fort",
+			titles: []string{"", "Agenda", "Agenda", ""},
+			want: []Chapter{
+				{Start: 0, End: 4, Title: "Folie 1"},
+				{Start: 4, End: 18, Title: "Agenda"},
+			},
+		},
+		{
+			name:   "ohne titel",
+			titles: nil,
+			want: []Chapter{
+				{Start: 0, End: 18, Title: "Folie 1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildChapters(timeline, tt.titles)
+			if len(got) != len(tt.want) {
+				t.Fatalf("BuildChapters() = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if !floatEqual(got[i].Start, tt.want[i].Start) || !floatEqual(got[i].End, tt.want[i].End) || got[i].Title != tt.want[i].Title {
+					t.Errorf("kapitel %d = %+v, want %+v", i+1, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormatFFMetadata(t *testing.T) {
+	chapters := []Chapter{
+		{Start: 0, End: 4.5, Title: "Intro"},
+		{Start: 4.5, End: 10, Title: "a=b; #1 \\ c"},
+	}
+
+	want := ";FFMETADATA1\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=4500\ntitle=Intro\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=4500\nEND=10000\ntitle=a\\=b\\; \\#1 \\\\ c\n"
+
+	if got := FormatFFMetadata(chapters); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestFormatYouTubeChapters(t *testing.T) {
+	tests := []struct {
+		name     string
+		chapters []Chapter
+		want     string
+	}{
+		{
+			name:     "keine kapitel",
+			chapters: nil,
+			want:     "",
+		},
+		{
+			name: "unter einer stunde",
+			chapters: []Chapter{
+				{Start: 0, Title: "Intro"},
+				{Start: 75.9, Title: "Agenda"},
+			},
+			want: "00:00 Intro\n01:15 Agenda\n",
+		},
+		{
+			name: "mit stunden",
+			chapters: []Chapter{
+				{Start: 0, Title: "Intro"},
+				{Start: 3723, Title: "Ende"},
+			},
+			want: "0:00:00 Intro\n1:02:03 Ende\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatYouTubeChapters(tt.chapters); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,84 @@
 package domain
 
+import (
+	"regexp"
+	"slices"
+)
+
+const (
+	MaxSlideDuration = 60.0
+	MaxAudioFade     = 10.0
+	DefaultAudioFade = 2.0
+
+	MaxNarrationPadding     = 10.0
+	DefaultNarrationPadding = 1.0
+
+	DefaultLanguage = "de"
+
+	TTSEngineEspeak = "espeak-ng"
+	TTSEnginePiper  = "piper"
+)
+
+var (
+	languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+	voicePattern    = regexp.MustCompile(`^[A-Za-z0-9_.+-]{1,64}$`)
+	colorPattern    = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// VoiceConfig wählt Sprache und Stimme der Sprachausgabe. Die Bedeutung
+// von Voice hängt von der Engine ab (espeak-ng: Variante, piper: Modell).
+type VoiceConfig struct {
+	Language string `json:"language"`
+	Voice    string `json:"voice,omitempty"`
+}
+
+// VoiceRequired gibt an, ob die Engine ohne ausdrücklich gewählte Stimme
+// nicht sprechen kann. Piper hat kein Standardmodell.
+func VoiceRequired(engine string) bool {
+	return engine == TTSEnginePiper
+}
+
 type ConversionConfig struct {
-	FPS                int     `json:"fps" binding:"required,min=1,max=60"`
-	Resolution         int     `json:"resolution" binding:"required,oneof=720 1080 1440 2160"`
+	FPS        int `json:"fps" binding:"required,min=1,max=60"`
+	Resolution int `json:"resolution" binding:"omitempty,oneof=720 1080 1440 2160"`
+	// Width und Height legen ein beliebiges Ausgabeformat fest (z.B. 1080x1920
+	// für Hochformat) und haben Vorrang vor Resolution.
+	Width              int     `json:"width,omitempty"`
+	Height             int     `json:"height,omitempty"`
 	Duration           int     `json:"duration" binding:"required,min=1,max=60"`
 	TransitionDuration float64 `json:"transitionDuration"`
+	UseDeckTimings     bool    `json:"useDeckTimings"`
+	// SlideDurations überschreibt die Dauer einzelner Slides in Sekunden.
+	// Schlüssel ist die Position der Slide im Video (1-basiert).
+	SlideDurations map[int]float64 `json:"slideDurations,omitempty"`
+	// AudioFadeDuration ist die Ein- und Ausblendzeit der Hintergrundmusik.
+	AudioFadeDuration float64 `json:"audioFadeDuration"`
+	// UseNarration übernimmt aufgezeichnete Kommentare aus der PPTX.
+	// NarrationPadding ist die Pause nach jedem Kommentar in Sekunden.
+	UseNarration     bool    `json:"useNarration"`
+	NarrationPadding float64 `json:"narrationPadding"`
+	// SpeakNotes vertont die Sprechernotizen per Sprachausgabe.
+	SpeakNotes bool        `json:"speakNotes"`
+	Voice      VoiceConfig `json:"voice"`
+	// SubtitleMode erzeugt Untertitel aus den Sprechernotizen.
+	SubtitleMode string `json:"subtitleMode,omitempty"`
+	// Chapters schreibt die Slide-Titel als Kapitelmarken ins Video.
+	Chapters bool `json:"chapters"`
+	// Transition ist der Standard-Übergang zwischen zwei Slides.
+	// SlideTransitions überschreibt den Übergang in einzelne Slides
+	// (Position im Video, 1-basiert; ab 2).
+	Transition       string         `json:"transition"`
+	SlideTransitions map[int]string `json:"slideTransitions,omitempty"`
+	// FitMode legt fest, wie Slides mit abweichendem Seitenverhältnis in das
+	// Ausgabebild eingepasst werden. BackgroundColor füllt dabei freie Ränder.
+	FitMode         string `json:"fitMode"`
+	BackgroundColor string `json:"backgroundColor"`
+	// OutputFormat wählt Container und Codecs der Ausgabedatei.
+	OutputFormat string `json:"outputFormat"`
+	// Quality ist der Name des gewählten Qualitätsprofils, Encoder enthält
+	// die daraus abgeleiteten und ggf. einzeln überschriebenen Einstellungen.
+	Quality string          `json:"quality"`
+	Encoder EncoderSettings `json:"encoder"`
 }
 
 func NewConversionConfig(fps, resolution, duration int, transitionDuration float64) (*ConversionConfig, error) {
@@ -27,7 +101,11 @@ func (c *ConversionConfig) Validate() error {
 		return ErrInvalidConfig
 	}
 
-	if c.Resolution != 720 && c.Resolution != 1080 && c.Resolution != 1440 && c.Resolution != 2160 {
+	if c.Width == 0 && c.Height == 0 {
+		if c.Resolution != 720 && c.Resolution != 1080 && c.Resolution != 1440 && c.Resolution != 2160 {
+			return ErrInvalidConfig
+		}
+	} else if !validFrameDimension(c.Width) || !validFrameDimension(c.Height) {
 		return ErrInvalidConfig
 	}
 
@@ -39,6 +117,68 @@ func (c *ConversionConfig) Validate() error {
 		return ErrInvalidConfig
 	}
 
+	if c.AudioFadeDuration < 0 || c.AudioFadeDuration > MaxAudioFade {
+		return ErrInvalidConfig
+	}
+
+	if c.NarrationPadding < 0 || c.NarrationPadding > MaxNarrationPadding {
+		return ErrInvalidConfig
+	}
+
+	if c.SpeakNotes {
+		if !languagePattern.MatchString(c.Voice.Language) {
+			return ErrInvalidConfig
+		}
+		if c.Voice.Voice != "" && !voicePattern.MatchString(c.Voice.Voice) {
+			return ErrInvalidConfig
+		}
+	}
+
+	if c.SubtitleMode != SubtitleModeNone && !slices.Contains(SubtitleModes, c.SubtitleMode) {
+		return ErrInvalidConfig
+	}
+
+	if c.Transition != "" && !IsValidTransition(c.Transition) {
+		return ErrInvalidConfig
+	}
+
+	if c.FitMode != "" && !slices.Contains(FitModes, c.FitMode) {
+		return ErrInvalidConfig
+	}
+
+	if c.OutputFormat != "" && !slices.Contains(OutputFormats, c.OutputFormat) {
+		return ErrInvalidConfig
+	}
+
+	if c.Quality != "" && !slices.Contains(QualityNames, c.Quality) {
+		return ErrInvalidConfig
+	}
+
+	if !c.Encoder.valid(c.Format()) {
+		return ErrInvalidConfig
+	}
+
+	// GIF hat keine Untertitelspur, Untertitel können nur ins Bild gerendert werden.
+	if c.Format() == OutputFormatGIF && c.SubtitleMode == SubtitleModeSoft {
+		return ErrInvalidConfig
+	}
+
+	if c.BackgroundColor != "" && !colorPattern.MatchString(c.BackgroundColor) {
+		return ErrInvalidConfig
+	}
+
+	for slide, transition := range c.SlideTransitions {
+		if slide < 2 || !IsValidTransition(transition) {
+			return ErrInvalidConfig
+		}
+	}
+
+	for slide, duration := range c.SlideDurations {
+		if slide < 1 || duration <= 0 || duration > MaxSlideDuration {
+			return ErrInvalidConfig
+		}
+	}
+
 	return nil
 }
 
@@ -48,5 +188,20 @@ func DefaultConfig() *ConversionConfig {
 		Resolution:         1080,
 		Duration:           5,
 		TransitionDuration: 1.0,
+		AudioFadeDuration:  DefaultAudioFade,
+		NarrationPadding:   DefaultNarrationPadding,
+		Voice:              VoiceConfig{Language: DefaultLanguage},
+		Transition:         TransitionFade,
+		FitMode:            FitModeLetterbox,
+		BackgroundColor:    DefaultBackgroundColor,
+		OutputFormat:       OutputFormatMP4,
+		Quality:            DefaultQuality,
+		Encoder:            QualityProfile(DefaultQuality, OutputFormatMP4),
 	}
 }
+
+// ReadsDeck gibt an, ob Inhalte aus der PPTX (Zeitvorgaben, Kommentare,
+// Notizen, Titel) für die Konvertierung benötigt werden.
+func (c *ConversionConfig) ReadsDeck() bool {
+	return c.UseDeckTimings || c.UseNarration || c.SpeakNotes || c.SubtitleMode != SubtitleModeNone || c.Chapters
+}
@@ -0,0 +1,28 @@
+package domain
+
+type Deck struct {
+	Slides []DeckSlide `json:"slides"`
+}
+
+type DeckSlide struct {
+	Number       int     `json:"number"`
+	Title        string  `json:"title,omitempty"`
+	Hidden       bool    `json:"hidden"`
+	AdvanceAfter float64 `json:"advanceAfter,omitempty"`
+	Transition   string  `json:"transition,omitempty"`
+	// Narration ist der Part-Name der aufgezeichneten Audiodatei in der PPTX.
+	Narration string `json:"narration,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// VisibleSlides liefert die Slides, die LibreOffice beim PDF-Export ausgibt.
+// Ausgeblendete Slides erzeugen keine PDF-Seite und damit kein Bild.
+func (d *Deck) VisibleSlides() []DeckSlide {
+	slides := make([]DeckSlide, 0, len(d.Slides))
+	for _, slide := range d.Slides {
+		if !slide.Hidden {
+			slides = append(slides, slide)
+		}
+	}
+	return slides
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+)
+
+const (
+	QualityDraft   = "draft"
+	QualityWeb     = "web"
+	QualityArchive = "archive"
+
+	DefaultQuality = QualityWeb
+
+	// MaxVideoBitrate begrenzt Bitraten (kbit/s) auf einen sinnvollen Bereich.
+	MaxVideoBitrate = 100000
+)
+
+var (
+	Presets      = []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"}
+	H264Profiles = []string{"baseline", "main", "high"}
+
+	levelPattern = regexp.MustCompile(`^[1-6](\.[0-2])?$`)
+)
+
+// EncoderSettings steuert Qualität und Dateigröße des Videos. Nullwerte
+// überlassen die Wahl dem Encoder. Ist VideoBitrate gesetzt, wird mit
+// Zielbitrate statt mit konstanter Qualität (CRF) kodiert. Bitraten sind
+// in kbit/s angegeben.
+type EncoderSettings struct {
+	CRF          int    `json:"crf,omitempty"`
+	VideoBitrate int    `json:"videoBitrate,omitempty"`
+	Preset       string `json:"preset,omitempty"`
+	MaxRate      int    `json:"maxRate,omitempty"`
+	BufSize      int    `json:"bufSize,omitempty"`
+	TwoPass      bool   `json:"twoPass"`
+	// Profile und Level gelten nur für H.264 (mp4, mov).
+	Profile string `json:"profile,omitempty"`
+	Level   string `json:"level,omitempty"`
+	// FastStart verschiebt den Index an den Dateianfang, damit die
+	// Wiedergabe im Browser vor dem vollständigen Download beginnt.
+	FastStart bool `json:"fastStart"`
+}
+
+// QualityProfiles sind die vordefinierten Einstellungen, die per Name
+// gewählt und anschließend einzeln überschrieben werden können.
+var QualityProfiles = map[string]EncoderSettings{
+	QualityDraft: {
+		CRF:       30,
+		Preset:    "veryfast",
+		FastStart: true,
+	},
+	QualityWeb: {
+		CRF:       23,
+		Preset:    "medium",
+		MaxRate:   8000,
+		BufSize:   16000,
+		Profile:   "high",
+		FastStart: true,
+	},
+	QualityArchive: {
+		CRF:    18,
+		Preset: "slow",
+	},
+}
+
+// QualityNames liefert die Namen der Qualitätsprofile in aufsteigender Qualität.
+var QualityNames = []string{QualityDraft, QualityWeb, QualityArchive}
+
+// QualityCRF enthält die CRF-Werte der Profile für Formate, deren Encoder
+// eine andere Skala als x264 verwenden. Die Werte in QualityProfiles gelten
+// für H.264.
+var QualityCRF = map[string]map[string]int{
+	OutputFormatHEVC: {QualityDraft: 35, QualityWeb: 28, QualityArchive: 23},
+	OutputFormatWebM: {QualityDraft: 40, QualityWeb: 32, QualityArchive: 24},
+}
+
+// QualityProfile liefert die Einstellungen des Profils quality mit dem
+// CRF-Wert für das Ausgabeformat.
+func QualityProfile(quality, format string) EncoderSettings {
+	settings := QualityProfiles[quality]
+	if crf, ok := QualityCRF[format][quality]; ok {
+		settings.CRF = crf
+	}
+	return settings
+}
+
+// MaxCRF liefert den größten gültigen CRF-Wert für das Ausgabeformat.
+func MaxCRF(format string) int {
+	if format == OutputFormatWebM {
+		return 63
+	}
+	return 51
+}
+
+func (s EncoderSettings) valid(format string) bool {
+	if s.CRF < 0 || s.CRF > MaxCRF(format) {
+		return false
+	}
+
+	if s.VideoBitrate < 0 || s.VideoBitrate > MaxVideoBitrate {
+		return false
+	}
+
+	if s.TwoPass && s.VideoBitrate == 0 {
+		return false
+	}
+
+	if s.Preset != "" && !slices.Contains(Presets, s.Preset) {
+		return false
+	}
+
+	if s.MaxRate < 0 || s.MaxRate > MaxVideoBitrate || s.BufSize < 0 || s.BufSize > 2*MaxVideoBitrate {
+		return false
+	}
+
+	// ffmpeg verlangt zu maxrate immer eine Puffergröße.
+	if s.MaxRate > 0 && s.BufSize == 0 {
+		return false
+	}
+
+	if s.VideoBitrate > 0 && s.MaxRate > 0 && s.MaxRate < s.VideoBitrate {
+		return false
+	}
+
+	if s.Profile != "" && !slices.Contains(H264Profiles, s.Profile) {
+		return false
+	}
+
+	if s.Level != "" && !levelPattern.MatchString(s.Level) {
+		return false
+	}
+
+	return true
+}
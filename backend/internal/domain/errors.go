@@ -17,4 +17,11 @@ var (
 	ErrFileNotFound       = errors.New("datei nicht gefunden")
 	ErrInvalidJobStatus   = errors.New("ungültiger Job-Status")
 	ErrStoragePathInvalid = errors.New("ungültiger Speicherpfad")
+	ErrInvalidAudioFile   = errors.New("ungültige Audiodatei")
+	ErrSpeechSynthesis    = errors.New("sprachausgabe fehlgeschlagen")
+	ErrTTSNotConfigured   = errors.New("keine Sprachausgabe konfiguriert")
+	ErrTimeout            = errors.New("zeitüberschreitung")
+	ErrJobCancelled       = errors.New("job abgebrochen")
+	ErrQueueFull          = errors.New("warteschlange voll")
+	ErrJobInterrupted     = errors.New("verarbeitung durch Neustart des Servers unterbrochen")
 )
@@ -0,0 +1,73 @@
+package domain
+
+import "math"
+
+const (
+	FitModeLetterbox = "letterbox"
+	FitModeCrop      = "crop"
+	FitModeStretch   = "stretch"
+	// FitModeBlur füllt die Ränder mit einer unscharfen, formatfüllenden
+	// Kopie der Slide statt mit einer festen Farbe.
+	FitModeBlur = "blur"
+
+	DefaultBackgroundColor = "#000000"
+
+	MinFrameDimension = 240
+	MaxFrameDimension = 4096
+)
+
+var FitModes = []string{FitModeLetterbox, FitModeBlur, FitModeCrop, FitModeStretch}
+
+// FramePreset ist ein gängiges Ausgabeformat, z.B. für soziale Netzwerke.
+type FramePreset struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+var FramePresets = []FramePreset{
+	{Name: "landscape", Width: 1920, Height: 1080},
+	{Name: "vertical", Width: 1080, Height: 1920},
+	{Name: "square", Width: 1080, Height: 1080},
+	{Name: "portrait", Width: 1080, Height: 1350},
+}
+
+// FrameSize liefert die Größe des Ausgabebildes. Sind Width und Height
+// gesetzt, werden sie direkt verwendet; sonst ist Resolution die Höhe und
+// die Breite ergibt sich aus dem Seitenverhältnis 16:9.
+func (c *ConversionConfig) FrameSize() (int, int) {
+	if c.Width > 0 && c.Height > 0 {
+		return c.Width, c.Height
+	}
+	return evenSize(float64(c.Resolution) * 16 / 9), c.Resolution
+}
+
+// validFrameDimension prüft eine explizite Kantenlänge. yuv420p verlangt
+// gerade Werte.
+func validFrameDimension(value int) bool {
+	return value >= MinFrameDimension && value <= MaxFrameDimension && value%2 == 0
+}
+
+// RasterSize liefert die Pixelgröße, in der eine Slide mit dem gegebenen
+// Seitenverhältnis gerendert werden muss, damit sie ohne erneute Skalierung
+// gemäß fitMode in das Ausgabebild passt.
+func RasterSize(frameWidth, frameHeight int, slideAspect float64, fitMode string) (int, int) {
+	frameAspect := float64(frameWidth) / float64(frameHeight)
+	if slideAspect <= 0 || fitMode == FitModeStretch {
+		return frameWidth, frameHeight
+	}
+
+	fitWidth := slideAspect > frameAspect
+	if fitMode == FitModeCrop {
+		fitWidth = !fitWidth
+	}
+
+	if fitWidth {
+		return frameWidth, evenSize(float64(frameWidth) / slideAspect)
+	}
+	return evenSize(float64(frameHeight) * slideAspect), frameHeight
+}
+
+func evenSize(value float64) int {
+	return int(math.Round(value/2)) * 2
+}
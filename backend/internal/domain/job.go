@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,19 +14,30 @@ const (
 	JobStatusProcessing JobStatus = "processing"
 	JobStatusCompleted  JobStatus = "completed"
 	JobStatusFailed     JobStatus = "failed"
+	JobStatusCancelled  JobStatus = "cancelled"
 )
 
 type Job struct {
-	ID             string            `json:"jobId"`
-	Status         JobStatus         `json:"status"`
-	Progress       int               `json:"progress"`
-	Error          string            `json:"error,omitempty"`
-	Config         *ConversionConfig `json:"config"`
-	OriginalFile   string            `json:"originalFile"`
-	OutputFile     string            `json:"outputFile,omitempty"`
-	CreatedAt      time.Time         `json:"createdAt"`
-	UpdatedAt      time.Time         `json:"updatedAt"`
-	CompletedAt    *time.Time        `json:"completedAt,omitempty"`
+	ID           string            `json:"jobId"`
+	Status       JobStatus         `json:"status"`
+	Progress     int               `json:"progress"`
+	Stage        JobStage          `json:"stage,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Config       *ConversionConfig `json:"config"`
+	OriginalFile string            `json:"originalFile"`
+	AudioFile    string            `json:"audioFile,omitempty"`
+	OutputFile   string            `json:"outputFile,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+	StartedAt    *time.Time        `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time        `json:"completedAt,omitempty"`
+	DownloadedAt *time.Time        `json:"downloadedAt,omitempty"`
+	Subtitles    []Cue             `json:"subtitles,omitempty"`
+	Chapters     []Chapter         `json:"chapters,omitempty"`
+	// Attempts zählt die Verarbeitungsversuche, Checkpoints die dabei
+	// abgeschlossenen Schritte.
+	Attempts    int         `json:"attempts"`
+	Checkpoints Checkpoints `json:"checkpoints"`
 }
 
 func NewJob(originalFile string, config *ConversionConfig) *Job {
@@ -45,7 +57,12 @@ func (j *Job) UpdateStatus(status JobStatus) {
 	j.Status = status
 	j.UpdatedAt = time.Now()
 
-	if status == JobStatusCompleted || status == JobStatusFailed {
+	if status == JobStatusProcessing {
+		now := time.Now()
+		j.StartedAt = &now
+	}
+
+	if status == JobStatusCompleted || status == JobStatusFailed || status == JobStatusCancelled {
 		now := time.Now()
 		j.CompletedAt = &now
 	}
@@ -62,6 +79,42 @@ func (j *Job) UpdateProgress(progress int) {
 	j.UpdatedAt = time.Now()
 }
 
+// Reset setzt einen Job für einen erneuten Versuch auf wartend zurück.
+// Checkpoints und Versuche bleiben erhalten.
+func (j *Job) Reset() {
+	j.Status = JobStatusPending
+	j.Progress = 0
+	j.Stage = ""
+	j.Error = ""
+	j.StartedAt = nil
+	j.CompletedAt = nil
+	j.DownloadedAt = nil
+	j.UpdatedAt = time.Now()
+}
+
+// Clone liefert eine Kopie des Jobs, die unabhängig vom Original geändert
+// werden kann. Config wird nach dem Anlegen des Jobs nicht mehr geändert und
+// daher geteilt.
+func (j *Job) Clone() *Job {
+	clone := *j
+	if j.StartedAt != nil {
+		startedAt := *j.StartedAt
+		clone.StartedAt = &startedAt
+	}
+	if j.CompletedAt != nil {
+		completedAt := *j.CompletedAt
+		clone.CompletedAt = &completedAt
+	}
+	if j.DownloadedAt != nil {
+		downloadedAt := *j.DownloadedAt
+		clone.DownloadedAt = &downloadedAt
+	}
+	clone.Subtitles = slices.Clone(j.Subtitles)
+	clone.Chapters = slices.Clone(j.Chapters)
+	clone.Checkpoints = j.Checkpoints.Clone()
+	return &clone
+}
+
 func (j *Job) SetError(err error) {
 	j.Status = JobStatusFailed
 	j.Error = err.Error()
@@ -70,6 +123,13 @@ func (j *Job) SetError(err error) {
 	j.UpdatedAt = now
 }
 
+// MarkDownloaded hält fest, dass das Ergebnis heruntergeladen wurde.
+func (j *Job) MarkDownloaded() {
+	now := time.Now()
+	j.DownloadedAt = &now
+	j.UpdatedAt = now
+}
+
 func (j *Job) SetOutputFile(outputFile string) {
 	j.OutputFile = outputFile
 	j.UpdatedAt = time.Now()
@@ -83,6 +143,10 @@ func (j *Job) IsFailed() bool {
 	return j.Status == JobStatusFailed
 }
 
+func (j *Job) IsCancelled() bool {
+	return j.Status == JobStatusCancelled
+}
+
 func (j *Job) IsProcessing() bool {
 	return j.Status == JobStatusProcessing || j.Status == JobStatusPending
 }
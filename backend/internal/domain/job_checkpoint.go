@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"maps"
+	"slices"
+)
+
+// Checkpoints hält fest, welche Schritte der Pipeline abgeschlossen sind und
+// wo ihre Ergebnisse im Temp-Verzeichnis liegen. Ein wiederholter oder nach
+// einem Neustart fortgesetzter Job überspringt diese Schritte.
+type Checkpoints struct {
+	Completed  []JobStage        `json:"completed,omitempty"`
+	PDFPath    string            `json:"pdfPath,omitempty"`
+	Images     []string          `json:"images,omitempty"`
+	Narrations map[int]Narration `json:"narrations,omitempty"`
+	OutputPath string            `json:"outputPath,omitempty"`
+}
+
+func (c Checkpoints) Clone() Checkpoints {
+	return Checkpoints{
+		Completed:  slices.Clone(c.Completed),
+		PDFPath:    c.PDFPath,
+		Images:     slices.Clone(c.Images),
+		Narrations: maps.Clone(c.Narrations),
+		OutputPath: c.OutputPath,
+	}
+}
+
+func (c *Checkpoints) Done(stage JobStage) bool {
+	return slices.Contains(c.Completed, stage)
+}
+
+func (c *Checkpoints) Complete(stage JobStage) {
+	if !c.Done(stage) {
+		c.Completed = append(c.Completed, stage)
+	}
+}
+
+// Reset verwirft den Checkpoint eines Schritts und aller späteren, da sie
+// auf seinem Ergebnis aufbauen.
+func (c *Checkpoints) Reset(stage JobStage) {
+	index := slices.IndexFunc(stageWeights, func(s stageWeight) bool {
+		return s.stage == stage
+	})
+	if index < 0 {
+		return
+	}
+
+	for _, later := range stageWeights[index:] {
+		c.Completed = slices.DeleteFunc(c.Completed, func(s JobStage) bool {
+			return s == later.stage
+		})
+		switch later.stage {
+		case JobStagePDF:
+			c.PDFPath = ""
+		case JobStageImages:
+			c.Images = nil
+		case JobStageNarration:
+			c.Narrations = nil
+		case JobStageEncode:
+			c.OutputPath = ""
+		}
+	}
+}
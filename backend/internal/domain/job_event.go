@@ -0,0 +1,61 @@
+package domain
+
+// JobEventType ist der Name eines Ereignisses im Event-Stream eines Jobs.
+type JobEventType string
+
+const (
+	JobEventStatus    JobEventType = "status"
+	JobEventStage     JobEventType = "stage"
+	JobEventProgress  JobEventType = "progress"
+	JobEventCompleted JobEventType = "completed"
+	JobEventFailed    JobEventType = "failed"
+	JobEventCancelled JobEventType = "cancelled"
+)
+
+// JobEvent ist eine Momentaufnahme des Job-Zustands zum Zeitpunkt eines
+// Ereignisses. QueuePosition ist die 1-basierte Position eines wartenden
+// Jobs.
+type JobEvent struct {
+	Type          JobEventType `json:"-"`
+	JobID         string       `json:"jobId"`
+	Status        JobStatus    `json:"status"`
+	Progress      int          `json:"progress"`
+	Stage         JobStage     `json:"stage,omitempty"`
+	ETASeconds    int          `json:"etaSeconds,omitempty"`
+	QueuePosition int          `json:"queuePosition,omitempty"`
+	Error         string       `json:"error,omitempty"`
+	DownloadURL   string       `json:"downloadUrl,omitempty"`
+}
+
+// Event erzeugt ein Ereignis mit dem aktuellen Zustand des Jobs. Für
+// beendete Jobs ergibt sich der Typ aus dem Status.
+func (j *Job) Event(eventType JobEventType) JobEvent {
+	switch j.Status {
+	case JobStatusCompleted:
+		eventType = JobEventCompleted
+	case JobStatusFailed:
+		eventType = JobEventFailed
+	case JobStatusCancelled:
+		eventType = JobEventCancelled
+	}
+
+	event := JobEvent{
+		Type:     eventType,
+		JobID:    j.ID,
+		Status:   j.Status,
+		Progress: j.Progress,
+		Error:    j.Error,
+	}
+	if j.IsProcessing() {
+		event.Stage = j.Stage
+	}
+	if eta, ok := j.ETA(); ok {
+		event.ETASeconds = int(eta.Seconds())
+	}
+	return event
+}
+
+// IsFinal gibt an, ob nach diesem Ereignis keine weiteren folgen.
+func (e JobEvent) IsFinal() bool {
+	return e.Type == JobEventCompleted || e.Type == JobEventFailed || e.Type == JobEventCancelled
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// JobStage ist der aktuelle Schritt der Konvertierungs-Pipeline.
+type JobStage string
+
+const (
+	JobStagePDF       JobStage = "pdf"
+	JobStageImages    JobStage = "images"
+	JobStageNarration JobStage = "narration"
+	JobStageEncode    JobStage = "encode"
+)
+
+// minETAProgress ist der Fortschritt, ab dem die Restlaufzeit geschätzt wird.
+// Davor ist die Hochrechnung zu ungenau.
+const minETAProgress = 5
+
+type stageWeight struct {
+	stage  JobStage
+	weight float64
+}
+
+// stageWeights gibt den Anteil jedes Schritts an der Gesamtlaufzeit in
+// Prozent an. Das Encoding dauert mit Abstand am längsten.
+var stageWeights = []stageWeight{
+	{JobStagePDF, 15},
+	{JobStageImages, 15},
+	{JobStageNarration, 10},
+	{JobStageEncode, 60},
+}
+
+// stages liefert die Schritte, die für diese Konfiguration ausgeführt
+// werden, mit ihrem Gewicht.
+func (c *ConversionConfig) stages() []stageWeight {
+	if c.UseNarration || c.SpeakNotes {
+		return stageWeights
+	}
+
+	stages := make([]stageWeight, 0, len(stageWeights))
+	for _, stage := range stageWeights {
+		if stage.stage != JobStageNarration {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+// UpdateStageProgress setzt den aktuellen Schritt und berechnet aus dessen
+// Fortschritt (0 bis 1) den gewichteten Gesamtfortschritt. Der Fortschritt
+// sinkt nie und erreicht 100 erst mit Abschluss des Jobs. Schritte, die für
+// die Konfiguration nicht vorgesehen sind, ändern ihn nicht.
+func (j *Job) UpdateStageProgress(stage JobStage, fraction float64) {
+	fraction = min(max(fraction, 0), 1)
+
+	stages := j.Config.stages()
+	total, done := 0.0, 0.0
+	found := false
+	for _, s := range stages {
+		if s.stage == stage {
+			done += s.weight * fraction
+			found = true
+		} else if !found {
+			done += s.weight
+		}
+		total += s.weight
+	}
+
+	j.Stage = stage
+	j.UpdatedAt = time.Now()
+	if !found {
+		return
+	}
+
+	progress := min(int(100*done/total), 99)
+	if progress > j.Progress {
+		j.Progress = progress
+	}
+}
+
+// SyncProgress übernimmt Fortschritt, Checkpoints und Ergebnisse, die die
+// Pipeline in ihrer Arbeitskopie des Jobs gesetzt hat. Status und Fehler
+// bleiben unverändert.
+func (j *Job) SyncProgress(from *Job) {
+	j.Progress = from.Progress
+	j.Stage = from.Stage
+	j.Checkpoints = from.Checkpoints.Clone()
+	j.OutputFile = from.OutputFile
+	j.Subtitles = slices.Clone(from.Subtitles)
+	j.Chapters = slices.Clone(from.Chapters)
+	j.UpdatedAt = from.UpdatedAt
+}
+
+// ETA schätzt die Restlaufzeit eines laufenden Jobs aus der bisherigen
+// Laufzeit und dem Fortschritt.
+func (j *Job) ETA() (time.Duration, bool) {
+	if j.Status != JobStatusProcessing || j.StartedAt == nil || j.Progress < minETAProgress {
+		return 0, false
+	}
+
+	elapsed := time.Since(*j.StartedAt)
+	remaining := elapsed * time.Duration(100-j.Progress) / time.Duration(j.Progress)
+	return remaining.Round(time.Second), true
+}
@@ -0,0 +1,38 @@
+package domain
+
+const (
+	OutputFormatMP4  = "mp4"
+	OutputFormatHEVC = "hevc"
+	OutputFormatWebM = "webm"
+	OutputFormatGIF  = "gif"
+	OutputFormatMOV  = "mov"
+)
+
+var OutputFormats = []string{OutputFormatMP4, OutputFormatHEVC, OutputFormatWebM, OutputFormatGIF, OutputFormatMOV}
+
+// OutputFormatSpec beschreibt Dateiendung und Content-Type eines Ausgabeformats.
+type OutputFormatSpec struct {
+	Extension   string
+	ContentType string
+}
+
+var outputFormatSpecs = map[string]OutputFormatSpec{
+	OutputFormatMP4:  {Extension: ".mp4", ContentType: "video/mp4"},
+	OutputFormatHEVC: {Extension: ".mp4", ContentType: "video/mp4"},
+	OutputFormatWebM: {Extension: ".webm", ContentType: "video/webm"},
+	OutputFormatGIF:  {Extension: ".gif", ContentType: "image/gif"},
+	OutputFormatMOV:  {Extension: ".mov", ContentType: "video/quicktime"},
+}
+
+// Format liefert das Ausgabeformat des Jobs, ohne Angabe MP4 (H.264).
+func (c *ConversionConfig) Format() string {
+	if c.OutputFormat == "" {
+		return OutputFormatMP4
+	}
+	return c.OutputFormat
+}
+
+// FormatSpec liefert Dateiendung und Content-Type des Ausgabeformats.
+func (c *ConversionConfig) FormatSpec() OutputFormatSpec {
+	return outputFormatSpecs[c.Format()]
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	SubtitleModeNone    = ""
+	SubtitleModeSoft    = "soft"
+	SubtitleModeSidecar = "sidecar"
+	SubtitleModeBurnIn  = "burnin"
+
+	// maxCueLength entspricht zwei Untertitelzeilen à 42 Zeichen.
+	maxCueLength = 84
+)
+
+var SubtitleModes = []string{SubtitleModeSoft, SubtitleModeSidecar, SubtitleModeBurnIn}
+
+type Cue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// BuildCues verteilt die Notizen jeder Slide auf Untertitel innerhalb des
+// Zeitfensters, in dem die Slide vollständig sichtbar ist. Die Anzeigedauer
+// eines Untertitels ist proportional zu seiner Textlänge.
+// notes ist nach der Position der Slide im Video (0-basiert) indiziert.
+func BuildCues(timeline Timeline, notes []string) []Cue {
+	starts := timeline.StartTimes()
+
+	var cues []Cue
+	for i, timing := range timeline {
+		if i >= len(notes) {
+			break
+		}
+
+		chunks := splitCueText(notes[i])
+		if len(chunks) == 0 {
+			continue
+		}
+
+		windowStart := starts[i] + timing.TransitionDuration
+		windowEnd := starts[i] + timing.Duration
+		if i+1 < len(timeline) {
+			windowEnd -= timeline[i+1].TransitionDuration
+		}
+		if windowEnd <= windowStart {
+			continue
+		}
+
+		totalLength := 0
+		for _, chunk := range chunks {
+			totalLength += len([]rune(chunk))
+		}
+
+		position := windowStart
+		for _, chunk := range chunks {
+			share := float64(len([]rune(chunk))) / float64(totalLength)
+			end := position + share*(windowEnd-windowStart)
+			cues = append(cues, Cue{Start: position, End: end, Text: chunk})
+			position = end
+		}
+	}
+
+	return cues
+}
+
+// splitCueText zerlegt einen Text in Sätze und teilt zu lange Sätze an
+// Wortgrenzen.
+func splitCueText(text string) []string {
+	var chunks []string
+	for _, sentence := range splitSentences(text) {
+		chunks = append(chunks, wrapWords(sentence, maxCueLength)...)
+	}
+	return chunks
+}
+
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	runes := []rune(text)
+
+	flush := func() {
+		if sentence := strings.Join(strings.Fields(current.String()), " "); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		current.Reset()
+	}
+
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		if strings.ContainsRune(".!?…", r) && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			flush()
+		}
+	}
+	flush()
+
+	return sentences
+}
+
+func wrapWords(sentence string, maxLength int) []string {
+	var chunks []string
+	var current string
+
+	for _, word := range strings.Fields(sentence) {
+		if current != "" && len([]rune(current))+1+len([]rune(word)) > maxLength {
+			chunks = append(chunks, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+func FormatSRT(cues []Cue) string {
+	var b strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n",
+			i+1, formatCueTime(cue.Start, ","), formatCueTime(cue.End, ","), cue.Text)
+	}
+	return b.String()
+}
+
+func FormatWebVTT(cues []Cue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			formatCueTime(cue.Start, "."), formatCueTime(cue.End, "."), cue.Text)
+	}
+	return b.String()
+}
+
+func formatCueTime(seconds float64, msSeparator string) string {
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		ms/3600000, ms/60000%60, ms/1000%60, msSeparator, ms%1000)
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitCueText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "leer",
+			text: "  \n ",
+			want: nil,
+		},
+		{
+			name: "sätze",
+			text: "Willkommen! Heute geht es um Go. Fragen?",
+			want: []string{"Willkommen!", "Heute geht es um Go.", "Fragen?"},
+		},
+		{
+			name: "zeilenumbrüche und leerraum",
+			text: "Erster Punkt\n\n  Zweiter   Punkt",
+			want: []string{"Erster Punkt", "Zweiter Punkt"},
+		},
+		{
+			name: "punkt innerhalb eines wortes trennt nicht",
+			text: "Version 1.24 ist da.",
+			want: []string{"Version 1.24 ist da."},
+		},
+		{
+			name: "langer satz wird an wortgrenzen geteilt",
+			text: strings.Repeat("wort ", 20),
+			want: []string{
+				strings.TrimSpace(strings.Repeat("wort ", 17)),
+				strings.TrimSpace(strings.Repeat("wort ", 3)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitCueText(tt.text)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitCueText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			for _, chunk := range got {
+				if len([]rune(chunk)) > maxCueLength {
+					t.Errorf("untertitel zu lang: %d Zeichen", len([]rune(chunk)))
+				}
+			}
+		})
+	}
+}
+
+func TestBuildCues(t *testing.T) {
+	timeline := Timeline{
+		{Duration: 5},
+		{Duration: 6, Transition: TransitionFade, TransitionDuration: 1},
+		{Duration: 5, Transition: TransitionFade, TransitionDuration: 1},
+	}
+	notes := []string{"Eins. Zwei.", "", "Drei"}
+
+	want := []Cue{
+		{Start: 0, End: 2, Text: "Eins."},
+		{Start: 2, End: 4, Text: "Zwei."},
+		{Start: 10, End: 14, Text: "Drei"},
+	}
+
+	got := BuildCues(timeline, notes)
+	if len(got) != len(want) {
+		t.Fatalf("BuildCues() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !floatEqual(got[i].Start, want[i].Start) || !floatEqual(got[i].End, want[i].End) || got[i].Text != want[i].Text {
+			t.Errorf("cue %d = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestFormatSubtitles(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 2.5, Text: "Hallo"},
+		{Start: 3723.0456, End: 3725, Text: "Welt"},
+	}
+
+	tests := []struct {
+		name   string
+		format func([]Cue) string
+		want   string
+	}{
+		{
+			name:   "SRT",
+			format: FormatSRT,
+			want: "1\n00:00:00,000 --> 00:00:02,500\nHallo\n\n" +
+				"2\n01:02:03,046 --> 01:02:05,000\nWelt\n\n",
+		},
+		{
+			name:   "WebVTT",
+			format: FormatWebVTT,
+			want: "WEBVTT\n\n" +
+				"00:00:00.000 --> 00:00:02.500\nHallo\n\n" +
+				"01:02:03.046 --> 01:02:05.000\nWelt\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format(cues); got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
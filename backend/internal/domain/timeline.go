@@ -0,0 +1,147 @@
+package domain
+
+type SlideTiming struct {
+	Duration           float64 `json:"duration"`
+	Transition         string  `json:"transition,omitempty"`
+	TransitionDuration float64 `json:"transitionDuration"`
+	Narration          string  `json:"narration,omitempty"`
+}
+
+// Narration ist eine Audiodatei, die während einer Slide abgespielt wird.
+// Mit Stretch wird die Slide nur verlängert, falls die Narration nicht
+// hineinpasst, ansonsten bestimmt die Narration die Dauer der Slide.
+type Narration struct {
+	Path     string  `json:"path"`
+	Duration float64 `json:"duration"`
+	Stretch  bool    `json:"stretch,omitempty"`
+}
+
+// Timeline beschreibt Anzeigedauer und Übergang jeder Slide im Video.
+// Transition und TransitionDuration beziehen sich auf den Übergang von der
+// vorherigen Slide in diese Slide; bei der ersten Slide sind sie leer.
+type Timeline []SlideTiming
+
+// BuildTimeline ermittelt die Dauer jeder Slide. Vorrang haben (absteigend):
+// SlideDurations aus der Konfiguration, die Länge einer Narration,
+// die Zeitvorgabe aus der Präsentation und zuletzt Duration.
+// Für Übergänge gilt analog: SlideTransitions, Präsentation, Transition.
+// narrations ist nach der Position der Slide im Video (0-basiert) indiziert.
+func BuildTimeline(config *ConversionConfig, slideCount int, deckSlides []DeckSlide, narrations map[int]Narration) Timeline {
+	timeline := make(Timeline, slideCount)
+	// required ist die Dauer, die eine Narration nach dem Übergang in ihre
+	// Slide benötigt, 0 für Slides ohne Narration oder mit fester Dauer.
+	required := make([]float64, slideCount)
+
+	defaultTransition := config.Transition
+	if defaultTransition == "" {
+		defaultTransition = TransitionFade
+	}
+
+	for i := range timeline {
+		timing := SlideTiming{
+			Duration:   float64(config.Duration),
+			Transition: defaultTransition,
+		}
+
+		if i < len(deckSlides) {
+			if deckSlides[i].AdvanceAfter > 0 {
+				timing.Duration = deckSlides[i].AdvanceAfter
+			}
+			if deckSlides[i].Transition != "" {
+				timing.Transition = deckSlides[i].Transition
+			}
+		}
+
+		if narration, ok := narrations[i]; ok {
+			timing.Narration = narration.Path
+			required[i] = narration.Duration + config.NarrationPadding
+			if !narration.Stretch || required[i] > timing.Duration {
+				timing.Duration = required[i]
+			}
+		}
+
+		if duration, ok := config.SlideDurations[i+1]; ok {
+			timing.Duration = duration
+			required[i] = 0
+		}
+		if transition, ok := config.SlideTransitions[i+1]; ok {
+			timing.Transition = transition
+		}
+
+		timeline[i] = timing
+	}
+
+	timeline.resolveTransitions(config.TransitionDuration)
+
+	// Die Narration beginnt erst, wenn der Übergang in die Slide
+	// abgeschlossen ist. Erst jetzt steht fest, wie lang dieser tatsächlich
+	// ist. Eine längere Slide verkürzt keinen Übergang.
+	for i, duration := range required {
+		if duration > 0 && duration+timeline[i].TransitionDuration > timeline[i].Duration {
+			timeline[i].Duration = duration + timeline[i].TransitionDuration
+		}
+	}
+	return timeline
+}
+
+func (t Timeline) resolveTransitions(transitionDuration float64) {
+	for i := range t {
+		if i == 0 {
+			t[i].Transition = ""
+			t[i].TransitionDuration = 0
+			continue
+		}
+
+		if transitionDuration <= 0 || t[i].Transition == TransitionCut {
+			t[i].Transition = TransitionCut
+			t[i].TransitionDuration = 0
+			continue
+		}
+
+		// Der Übergang darf keine der beiden Slides vollständig überdecken,
+		// auch nicht zusammen mit ihrem anderen Übergang.
+		maxDuration := min(t.overlapLimit(i-1), t.overlapLimit(i))
+		duration := transitionDuration
+		if duration >= maxDuration {
+			duration = maxDuration / 2
+		}
+		t[i].TransitionDuration = duration
+	}
+}
+
+// overlapLimit liefert, wie viel von Slide i ein einzelner Übergang
+// überdecken darf: die ganze Dauer, wenn sie nur auf einer Seite einen
+// Übergang hat, sonst die Hälfte. Ein- und ausgehender Übergang überdecken
+// die Slide so zusammen nie vollständig.
+func (t Timeline) overlapLimit(i int) float64 {
+	if t.hasTransition(i) && t.hasTransition(i+1) {
+		return t[i].Duration / 2
+	}
+	return t[i].Duration
+}
+
+// hasTransition gibt an, ob in Slide i übergeblendet wird. Vor Slide 1 und
+// nach der letzten Slide gibt es keinen Übergang.
+func (t Timeline) hasTransition(i int) bool {
+	return i > 0 && i < len(t) && t[i].Transition != TransitionCut
+}
+
+// StartTimes liefert den Zeitpunkt, ab dem jede Slide im Video sichtbar wird
+// (Beginn des Übergangs in diese Slide).
+func (t Timeline) StartTimes() []float64 {
+	starts := make([]float64, len(t))
+	var end float64
+	for i, timing := range t {
+		starts[i] = end - timing.TransitionDuration
+		end = starts[i] + timing.Duration
+	}
+	return starts
+}
+
+func (t Timeline) TotalDuration() float64 {
+	var total float64
+	for _, timing := range t {
+		total += timing.Duration - timing.TransitionDuration
+	}
+	return total
+}
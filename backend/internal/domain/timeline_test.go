@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *ConversionConfig
+		slideCount int
+		deck       []DeckSlide
+		narrations map[int]Narration
+		want       Timeline
+	}{
+		{
+			name:       "standardwerte",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 1},
+			slideCount: 3,
+			want: Timeline{
+				{Duration: 5},
+				{Duration: 5, Transition: TransitionFade, TransitionDuration: 1},
+				{Duration: 5, Transition: TransitionFade, TransitionDuration: 1},
+			},
+		},
+		{
+			name:       "ohne Übergangsdauer nur harte Schnitte",
+			config:     &ConversionConfig{Duration: 5, Transition: "wipeleft"},
+			slideCount: 2,
+			want: Timeline{
+				{Duration: 5},
+				{Duration: 5, Transition: TransitionCut},
+			},
+		},
+		{
+			name:       "zeiten und übergänge der präsentation",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 1},
+			slideCount: 3,
+			deck: []DeckSlide{
+				{Number: 1, AdvanceAfter: 8},
+				{Number: 2, Transition: "wipeleft"},
+				{Number: 4, AdvanceAfter: 2.5, Transition: TransitionCut},
+			},
+			want: Timeline{
+				{Duration: 8},
+				{Duration: 5, Transition: "wipeleft", TransitionDuration: 1},
+				{Duration: 2.5, Transition: TransitionCut},
+			},
+		},
+		{
+			name: "konfiguration hat vorrang vor der präsentation",
+			config: &ConversionConfig{
+				Duration:           5,
+				TransitionDuration: 1,
+				SlideDurations:     map[int]float64{1: 12.5},
+				SlideTransitions:   map[int]string{2: TransitionCut},
+			},
+			slideCount: 2,
+			deck: []DeckSlide{
+				{Number: 1, AdvanceAfter: 8},
+				{Number: 2, Transition: "wipeleft"},
+			},
+			want: Timeline{
+				{Duration: 12.5},
+				{Duration: 5, Transition: TransitionCut},
+			},
+		},
+		{
+			name:       "kurze mittlere slide teilt sich auf beide übergänge auf",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 1, SlideDurations: map[int]float64{2: 1.5}},
+			slideCount: 3,
+			want: Timeline{
+				{Duration: 5},
+				{Duration: 1.5, Transition: TransitionFade, TransitionDuration: 0.375},
+				{Duration: 5, Transition: TransitionFade, TransitionDuration: 0.375},
+			},
+		},
+		{
+			name:       "randslide darf fast ganz überblendet werden",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 1.5, SlideDurations: map[int]float64{1: 2}},
+			slideCount: 2,
+			want: Timeline{
+				{Duration: 2},
+				{Duration: 5, Transition: TransitionFade, TransitionDuration: 1.5},
+			},
+		},
+		{
+			name:       "zu langer übergang wird halbiert",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 3, SlideDurations: map[int]float64{1: 2}},
+			slideCount: 2,
+			want: Timeline{
+				{Duration: 2},
+				{Duration: 5, Transition: TransitionFade, TransitionDuration: 1},
+			},
+		},
+		{
+			name:       "narration verlängert die slide um pause und übergang",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 1, NarrationPadding: 1},
+			slideCount: 2,
+			narrations: map[int]Narration{1: {Path: "n.wav", Duration: 6}},
+			want: Timeline{
+				{Duration: 5},
+				{Duration: 8, Transition: TransitionFade, TransitionDuration: 1, Narration: "n.wav"},
+			},
+		},
+		{
+			name:       "kurze sprachausgabe behält die dauer",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 1, NarrationPadding: 1},
+			slideCount: 2,
+			narrations: map[int]Narration{1: {Path: "n.wav", Duration: 2, Stretch: true}},
+			want: Timeline{
+				{Duration: 5},
+				{Duration: 5, Transition: TransitionFade, TransitionDuration: 1, Narration: "n.wav"},
+			},
+		},
+		{
+			name:       "feste dauer hat vorrang vor der narration",
+			config:     &ConversionConfig{Duration: 5, TransitionDuration: 1, SlideDurations: map[int]float64{1: 3}},
+			slideCount: 1,
+			narrations: map[int]Narration{0: {Path: "n.wav", Duration: 6}},
+			want: Timeline{
+				{Duration: 3, Narration: "n.wav"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildTimeline(tt.config, tt.slideCount, tt.deck, tt.narrations)
+			if len(got) != len(tt.want) {
+				t.Fatalf("len = %d, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !timingEqual(got[i], tt.want[i]) {
+					t.Errorf("slide %d = %+v, want %+v", i+1, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildTimelineTransitionsFitSlides(t *testing.T) {
+	durations := []float64{0.5, 1, 1.5, 2, 3, 10}
+	for _, transitionDuration := range []float64{0.5, 1, 2, 5} {
+		for _, duration := range durations {
+			config := &ConversionConfig{
+				Duration:           5,
+				TransitionDuration: transitionDuration,
+				SlideDurations:     map[int]float64{2: duration, 3: duration},
+			}
+			timeline := BuildTimeline(config, 4, nil, nil)
+
+			for i, timing := range timeline {
+				overlap := timing.TransitionDuration
+				if i+1 < len(timeline) {
+					overlap += timeline[i+1].TransitionDuration
+				}
+				if overlap >= timing.Duration {
+					t.Errorf("übergang %.1fs, slide %d (%.1fs) vollständig überblendet: %.3fs",
+						transitionDuration, i+1, timing.Duration, overlap)
+				}
+			}
+		}
+	}
+}
+
+func TestTimelineStartTimes(t *testing.T) {
+	timeline := Timeline{
+		{Duration: 5},
+		{Duration: 4, Transition: TransitionFade, TransitionDuration: 1},
+		{Duration: 3, Transition: TransitionCut},
+	}
+
+	want := []float64{0, 4, 8}
+	got := timeline.StartTimes()
+	for i := range want {
+		if !floatEqual(got[i], want[i]) {
+			t.Errorf("start %d = %v, want %v", i+1, got[i], want[i])
+		}
+	}
+
+	if total := timeline.TotalDuration(); !floatEqual(total, 11) {
+		t.Errorf("TotalDuration() = %v, want 11", total)
+	}
+}
+
+func timingEqual(a, b SlideTiming) bool {
+	return floatEqual(a.Duration, b.Duration) &&
+		a.Transition == b.Transition &&
+		floatEqual(a.TransitionDuration, b.TransitionDuration) &&
+		a.Narration == b.Narration
+}
+
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
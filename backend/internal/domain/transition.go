@@ -0,0 +1,30 @@
+package domain
+
+import "slices"
+
+const (
+	TransitionCut  = "cut"
+	TransitionFade = "fade"
+)
+
+// Transitions enthält alle Übergänge des ffmpeg-Filters xfade sowie "cut"
+// für einen harten Schnitt.
+var Transitions = []string{
+	TransitionCut,
+	TransitionFade,
+	"fadeblack", "fadewhite", "fadegrays", "fadefast", "fadeslow",
+	"dissolve", "pixelize", "distance", "hblur",
+	"wipeleft", "wiperight", "wipeup", "wipedown",
+	"wipetl", "wipetr", "wipebl", "wipebr",
+	"slideleft", "slideright", "slideup", "slidedown",
+	"smoothleft", "smoothright", "smoothup", "smoothdown",
+	"circlecrop", "rectcrop", "circleopen", "circleclose", "radial",
+	"vertopen", "vertclose", "horzopen", "horzclose",
+	"diagtl", "diagtr", "diagbl", "diagbr",
+	"hlslice", "hrslice", "vuslice", "vdslice",
+	"squeezeh", "squeezev", "zoomin",
+}
+
+func IsValidTransition(name string) bool {
+	return slices.Contains(Transitions, name)
+}
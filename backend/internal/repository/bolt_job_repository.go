@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pptx2mp4/backend/internal/domain"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var jobsBucket = []byte("jobs")
+
+// BoltJobRepository speichert Jobs als JSON in einer eingebetteten
+// bbolt-Datenbank, sodass sie einen Neustart des Servers überstehen. Jede
+// Änderung über Create, Update oder Delete ist nach der Rückkehr auf der
+// Platte.
+type BoltJobRepository struct {
+	db     *bolt.DB
+	logger *logrus.Logger
+}
+
+// NewBoltJobRepository öffnet die Datenbank unter path oder legt sie an.
+// Ein zweiter Prozess auf derselben Datei wird nach kurzer Wartezeit
+// abgewiesen.
+func NewBoltJobRepository(path string, logger *logrus.Logger) (*BoltJobRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Datenbank-Verzeichnisses: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Öffnen der Job-Datenbank: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("fehler beim Anlegen der Job-Datenbank: %w", err)
+	}
+
+	return &BoltJobRepository{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func (r *BoltJobRepository) Create(job *domain.Job) error {
+	data, err := marshalJob(job)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket)
+		if bucket.Get([]byte(job.ID)) != nil {
+			return domain.ErrJobAlreadyExists
+		}
+		return bucket.Put([]byte(job.ID), data)
+	})
+}
+
+func (r *BoltJobRepository) FindByID(id string) (*domain.Job, error) {
+	var job *domain.Job
+	err := r.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(jobsBucket).Get([]byte(id))
+		if data == nil {
+			return domain.ErrJobNotFound
+		}
+
+		var err error
+		job, err = unmarshalJob(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *BoltJobRepository) Update(job *domain.Job) error {
+	data, err := marshalJob(job)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket)
+		if bucket.Get([]byte(job.ID)) == nil {
+			return domain.ErrJobNotFound
+		}
+		return bucket.Put([]byte(job.ID), data)
+	})
+}
+
+func (r *BoltJobRepository) Delete(id string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return domain.ErrJobNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+// FindAll liefert alle lesbaren Jobs. Nicht lesbare Einträge werden
+// übersprungen, damit ein einzelner defekter Job nicht den Start verhindert.
+func (r *BoltJobRepository) FindAll() ([]*domain.Job, error) {
+	var jobs []*domain.Job
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(key, data []byte) error {
+			job, err := unmarshalJob(data)
+			if err != nil {
+				r.logger.WithError(err).WithField("jobID", string(key)).Warn("gespeicherter Job ungültig, wird übersprungen")
+				return nil
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der Jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *BoltJobRepository) Close() error {
+	return r.db.Close()
+}
+
+func marshalJob(job *domain.Job) ([]byte, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Serialisieren des Jobs: %w", err)
+	}
+	return data, nil
+}
+
+func unmarshalJob(data []byte) (*domain.Job, error) {
+	var job domain.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen des Jobs: %w", err)
+	}
+	return &job, nil
+}
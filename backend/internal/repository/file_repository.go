@@ -3,21 +3,36 @@ package repository
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"os"
 	"path/filepath"
 	"pptx2mp4/backend/internal/domain"
+	"time"
 )
 
 type FileRepository interface {
 	SaveUpload(jobID string, file multipart.File, filename string) (string, error)
+	SaveBackgroundAudio(jobID string, file multipart.File, extension string) (string, error)
+	GetBackgroundAudioPath(jobID string) (string, error)
 	GetUploadPath(jobID string) string
 	GetTempPath(jobID string) string
 	GetOutputPath(jobID string) string
-	GetOutputFilePath(jobID string) string
+	GetOutputFilePath(jobID string, extension string) string
 	FileExists(path string) bool
 	EnsureDirectories(jobID string) error
 	CleanupJob(jobID string) error
+	// ListJobIDs liefert die IDs aller Jobs, für die Upload-, Temp- oder
+	// Ausgabeverzeichnisse existieren.
+	ListJobIDs() ([]string, error)
+	GetJobUsage(jobID string) (JobUsage, error)
+}
+
+// JobUsage beschreibt den Speicherplatz, den die Dateien eines Jobs belegen,
+// und wann sie zuletzt geändert wurden.
+type JobUsage struct {
+	Size    int64
+	ModTime time.Time
 }
 
 type FileSystemRepository struct {
@@ -50,6 +65,39 @@ func (r *FileSystemRepository) SaveUpload(jobID string, file multipart.File, fil
 	return destPath, nil
 }
 
+func (r *FileSystemRepository) SaveBackgroundAudio(jobID string, file multipart.File, extension string) (string, error) {
+	uploadDir := r.GetUploadPath(jobID)
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return "", fmt.Errorf("fehler beim Erstellen des Upload-Verzeichnisses: %w", err)
+	}
+
+	destPath := filepath.Join(uploadDir, "background"+extension)
+	destFile, err := os.Create(destPath)
+	if err != nil {
+		return "", fmt.Errorf("fehler beim Erstellen der Zieldatei: %w", err)
+	}
+	defer destFile.Close()
+
+	if _, err := io.Copy(destFile, file); err != nil {
+		return "", fmt.Errorf("fehler beim Kopieren der Datei: %w", err)
+	}
+
+	return destPath, nil
+}
+
+func (r *FileSystemRepository) GetBackgroundAudioPath(jobID string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(r.GetUploadPath(jobID), "background.*"))
+	if err != nil {
+		return "", fmt.Errorf("fehler beim Suchen der Audiodatei: %w", err)
+	}
+
+	if len(matches) == 0 {
+		return "", domain.ErrFileNotFound
+	}
+
+	return matches[0], nil
+}
+
 func (r *FileSystemRepository) GetUploadPath(jobID string) string {
 	return filepath.Join(r.basePath, "uploads", jobID)
 }
@@ -62,8 +110,8 @@ func (r *FileSystemRepository) GetOutputPath(jobID string) string {
 	return filepath.Join(r.basePath, "output", jobID)
 }
 
-func (r *FileSystemRepository) GetOutputFilePath(jobID string) string {
-	return filepath.Join(r.GetOutputPath(jobID), "output.mp4")
+func (r *FileSystemRepository) GetOutputFilePath(jobID string, extension string) string {
+	return filepath.Join(r.GetOutputPath(jobID), "output"+extension)
 }
 
 func (r *FileSystemRepository) FileExists(path string) bool {
@@ -103,6 +151,72 @@ func (r *FileSystemRepository) CleanupJob(jobID string) error {
 	return nil
 }
 
+// jobRoots sind die Verzeichnisse unter basePath, die je Job ein
+// Unterverzeichnis enthalten. Andere Einträge wie jobs.db oder office
+// gehören nicht zu einzelnen Jobs.
+var jobRoots = []string{"uploads", "temp", "output"}
+
+func (r *FileSystemRepository) ListJobIDs() ([]string, error) {
+	seen := make(map[string]bool)
+	var jobIDs []string
+
+	for _, root := range jobRoots {
+		entries, err := os.ReadDir(filepath.Join(r.basePath, root))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("fehler beim Lesen des Verzeichnisses %s: %w", root, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() && !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				jobIDs = append(jobIDs, entry.Name())
+			}
+		}
+	}
+
+	return jobIDs, nil
+}
+
+func (r *FileSystemRepository) GetJobUsage(jobID string) (JobUsage, error) {
+	var usage JobUsage
+	paths := []string{
+		r.GetUploadPath(jobID),
+		r.GetTempPath(jobID),
+		r.GetOutputPath(jobID),
+	}
+
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				usage.Size += info.Size()
+			}
+			if info.ModTime().After(usage.ModTime) {
+				usage.ModTime = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return usage, fmt.Errorf("fehler beim Lesen des Verzeichnisses %s: %w", path, err)
+		}
+	}
+
+	return usage, nil
+}
+
 func (r *FileSystemRepository) ValidateStoragePath() error {
 	if r.basePath == "" {
 		return domain.ErrStoragePathInvalid
@@ -5,6 +5,9 @@ import (
 	"sync"
 )
 
+// JobRepository speichert Jobs. Implementierungen geben nur Kopien heraus
+// und speichern Kopien, Änderungen an einem Job werden daher erst mit Update
+// sichtbar.
 type JobRepository interface {
 	Create(job *domain.Job) error
 	FindByID(id string) (*domain.Job, error)
@@ -32,7 +35,7 @@ func (r *InMemoryJobRepository) Create(job *domain.Job) error {
 		return domain.ErrJobAlreadyExists
 	}
 
-	r.jobs[job.ID] = job
+	r.jobs[job.ID] = job.Clone()
 	return nil
 }
 
@@ -45,7 +48,7 @@ func (r *InMemoryJobRepository) FindByID(id string) (*domain.Job, error) {
 		return nil, domain.ErrJobNotFound
 	}
 
-	return job, nil
+	return job.Clone(), nil
 }
 
 func (r *InMemoryJobRepository) Update(job *domain.Job) error {
@@ -56,7 +59,7 @@ func (r *InMemoryJobRepository) Update(job *domain.Job) error {
 		return domain.ErrJobNotFound
 	}
 
-	r.jobs[job.ID] = job
+	r.jobs[job.ID] = job.Clone()
 	return nil
 }
 
@@ -78,7 +81,7 @@ func (r *InMemoryJobRepository) FindAll() ([]*domain.Job, error) {
 
 	jobs := make([]*domain.Job, 0, len(r.jobs))
 	for _, job := range r.jobs {
-		jobs = append(jobs, job)
+		jobs = append(jobs, job.Clone())
 	}
 
 	return jobs, nil
@@ -1,44 +1,75 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"pptx2mp4/backend/internal/converter"
 	"pptx2mp4/backend/internal/domain"
 	"pptx2mp4/backend/internal/repository"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 type ConversionService interface {
-	Convert(job *domain.Job) error
+	// Convert führt die Pipeline für job aus. onUpdate wird aufgerufen, wenn
+	// sich Schritt oder Fortschritt des Jobs ändern.
+	Convert(ctx context.Context, job *domain.Job, onUpdate func(domain.JobEventType)) error
+}
+
+// StageTimeouts begrenzt die Laufzeit der einzelnen Schritte der Pipeline.
+// Ein Wert <= 0 bedeutet keine eigene Begrenzung.
+type StageTimeouts struct {
+	PDF       time.Duration
+	Images    time.Duration
+	Narration time.Duration
+	Encode    time.Duration
 }
 
 type ConversionServiceImpl struct {
-	fileRepo        repository.FileRepository
-	pptxConverter   converter.PPTXConverter
-	pdfConverter    converter.PDFToImagesConverter
-	videoEncoder    converter.VideoEncoder
-	logger          *logrus.Logger
+	fileRepo      repository.FileRepository
+	pptxConverter converter.PPTXConverter
+	pptxParser    converter.PPTXParser
+	pdfConverter  converter.PDFToImagesConverter
+	videoEncoder  converter.VideoEncoder
+	mediaProber   converter.MediaProber
+	synthesizer   converter.NarrationSynthesizer
+	timeouts      StageTimeouts
+	logger        *logrus.Logger
 }
 
 func NewConversionService(
 	fileRepo repository.FileRepository,
 	pptxConverter converter.PPTXConverter,
+	pptxParser converter.PPTXParser,
 	pdfConverter converter.PDFToImagesConverter,
 	videoEncoder converter.VideoEncoder,
+	mediaProber converter.MediaProber,
+	synthesizer converter.NarrationSynthesizer,
+	timeouts StageTimeouts,
 	logger *logrus.Logger,
 ) *ConversionServiceImpl {
 	return &ConversionServiceImpl{
 		fileRepo:      fileRepo,
 		pptxConverter: pptxConverter,
+		pptxParser:    pptxParser,
 		pdfConverter:  pdfConverter,
 		videoEncoder:  videoEncoder,
+		mediaProber:   mediaProber,
+		synthesizer:   synthesizer,
+		timeouts:      timeouts,
 		logger:        logger,
 	}
 }
 
-func (s *ConversionServiceImpl) Convert(job *domain.Job) error {
+// Convert führt die Pipeline aus. Wird ctx abgebrochen, werden laufende
+// externe Programme beendet und Convert kehrt mit ctx.Err() im Fehler zurück.
+// job ist eine Arbeitskopie, die nur Convert ändert; onUpdate wird nach jeder
+// Änderung aufgerufen, damit der Aufrufer sie übernehmen kann.
+func (s *ConversionServiceImpl) Convert(ctx context.Context, job *domain.Job, onUpdate func(domain.JobEventType)) error {
 	s.logger.WithField("jobID", job.ID).Info("starte Konvertierungs-Pipeline")
 
 	if err := s.fileRepo.EnsureDirectories(job.ID); err != nil {
@@ -47,7 +78,7 @@ func (s *ConversionServiceImpl) Convert(job *domain.Job) error {
 
 	uploadPath := filepath.Join(s.fileRepo.GetUploadPath(job.ID), "input.pptx")
 	tempPath := s.fileRepo.GetTempPath(job.ID)
-	outputPath := s.fileRepo.GetOutputFilePath(job.ID)
+	outputPath := s.fileRepo.GetOutputFilePath(job.ID, job.Config.FormatSpec().Extension)
 
 	s.logger.WithFields(logrus.Fields{
 		"jobID":      job.ID,
@@ -56,30 +87,109 @@ func (s *ConversionServiceImpl) Convert(job *domain.Job) error {
 		"outputPath": outputPath,
 	}).Debug("Pfade konfiguriert")
 
-	job.UpdateProgress(10)
+	pdfPath := job.Checkpoints.PDFPath
+	if !s.skipStage(job, onUpdate, domain.JobStagePDF, pdfPath) {
+		s.logger.WithField("jobID", job.ID).Info("schritt 1: PPTX zu PDF")
+		err := s.runStage(ctx, job, onUpdate, domain.JobStagePDF, s.timeouts.PDF, func(ctx context.Context, progress converter.ProgressFunc) error {
+			var err error
+			pdfPath, err = s.pptxConverter.ConvertToPDF(ctx, uploadPath, tempPath)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("PPTX zu PDF Konvertierung fehlgeschlagen: %w", err)
+		}
+		job.Checkpoints.PDFPath = pdfPath
+		job.Checkpoints.Complete(domain.JobStagePDF)
+	}
 
-	s.logger.WithField("jobID", job.ID).Info("schritt 1: PPTX zu PDF")
-	pdfPath, err := s.pptxConverter.ConvertToPDF(uploadPath, tempPath)
-	if err != nil {
-		return fmt.Errorf("PPTX zu PDF Konvertierung fehlgeschlagen: %w", err)
+	images := job.Checkpoints.Images
+	if !s.skipStage(job, onUpdate, domain.JobStageImages, images...) {
+		s.logger.WithField("jobID", job.ID).Info("schritt 2: PDF zu Bilder")
+		err := s.runStage(ctx, job, onUpdate, domain.JobStageImages, s.timeouts.Images, func(ctx context.Context, progress converter.ProgressFunc) error {
+			rasterWidth, rasterHeight := s.rasterSize(ctx, job, pdfPath)
+			var err error
+			images, err = s.pdfConverter.ConvertToImages(ctx, pdfPath, tempPath, rasterWidth, rasterHeight, progress)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("PDF zu Bilder Konvertierung fehlgeschlagen: %w", err)
+		}
+		job.Checkpoints.Images = images
+		job.Checkpoints.Complete(domain.JobStageImages)
 	}
-	job.UpdateProgress(40)
 
-	s.logger.WithField("jobID", job.ID).Info("schritt 2: PDF zu Bilder")
-	images, err := s.pdfConverter.ConvertToImages(pdfPath, tempPath, job.Config.Resolution)
-	if err != nil {
-		return fmt.Errorf("PDF zu Bilder Konvertierung fehlgeschlagen: %w", err)
+	deckSlides := s.deckSlides(job, uploadPath, len(images))
+	var narrations map[int]domain.Narration
+	if (job.Config.UseNarration || job.Config.SpeakNotes) && deckSlides != nil {
+		narrations = job.Checkpoints.Narrations
+		narrationPaths := make([]string, 0, len(narrations))
+		for _, narration := range narrations {
+			narrationPaths = append(narrationPaths, narration.Path)
+		}
+
+		if !s.skipStage(job, onUpdate, domain.JobStageNarration, narrationPaths...) {
+			err := s.runStage(ctx, job, onUpdate, domain.JobStageNarration, s.timeouts.Narration, func(ctx context.Context, progress converter.ProgressFunc) error {
+				var err error
+				narrations, err = s.collectNarrations(ctx, job, uploadPath, tempPath, deckSlides, progress)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("sprachausgabe fehlgeschlagen: %w", err)
+			}
+			job.Checkpoints.Narrations = narrations
+			job.Checkpoints.Complete(domain.JobStageNarration)
+		}
 	}
-	job.UpdateProgress(70)
+
+	var timingSlides []domain.DeckSlide
+	if job.Config.UseDeckTimings {
+		timingSlides = deckSlides
+	}
+	timeline := domain.BuildTimeline(job.Config, len(images), timingSlides, narrations)
 
 	s.logger.WithFields(logrus.Fields{
 		"jobID":      job.ID,
 		"imageCount": len(images),
 	}).Info("schritt 3: Bilder zu Video")
-	if err := s.videoEncoder.EncodeToMP4(tempPath, outputPath, job.Config); err != nil {
-		return fmt.Errorf("video-encoding fehlgeschlagen: %w", err)
+	encodeRequest := &converter.EncodeRequest{
+		ImagesDir:  tempPath,
+		OutputPath: outputPath,
+		Config:     job.Config,
+		Timeline:   timeline,
+	}
+	if job.Config.SubtitleMode != domain.SubtitleModeNone {
+		subtitlesPath, err := s.writeSubtitles(job, tempPath, timeline, deckSlides)
+		if err != nil {
+			return err
+		}
+		encodeRequest.SubtitlesPath = subtitlesPath
+	}
+	if job.Config.Chapters {
+		chaptersPath, err := s.writeChapters(job, tempPath, timeline, deckSlides)
+		if err != nil {
+			return err
+		}
+		encodeRequest.ChaptersPath = chaptersPath
+	}
+	if job.AudioFile != "" {
+		audioPath, err := s.fileRepo.GetBackgroundAudioPath(job.ID)
+		if err != nil {
+			return fmt.Errorf("hintergrundmusik nicht gefunden: %w", err)
+		}
+		encodeRequest.BackgroundAudio = audioPath
+	}
+
+	if !s.skipStage(job, onUpdate, domain.JobStageEncode, job.Checkpoints.OutputPath) {
+		err := s.runStage(ctx, job, onUpdate, domain.JobStageEncode, s.timeouts.Encode, func(ctx context.Context, progress converter.ProgressFunc) error {
+			encodeRequest.Progress = progress
+			return s.videoEncoder.Encode(ctx, encodeRequest)
+		})
+		if err != nil {
+			return fmt.Errorf("video-encoding fehlgeschlagen: %w", err)
+		}
+		job.Checkpoints.OutputPath = outputPath
+		job.Checkpoints.Complete(domain.JobStageEncode)
 	}
-	job.UpdateProgress(90)
 
 	job.SetOutputFile(outputPath)
 
@@ -91,11 +201,275 @@ func (s *ConversionServiceImpl) Convert(job *domain.Job) error {
 	return nil
 }
 
+// skipStage prüft, ob ein Schritt in einem früheren Versuch abgeschlossen
+// wurde und seine Ergebnisse noch vorhanden sind. Dann wird er als erledigt
+// gemeldet und übersprungen.
+func (s *ConversionServiceImpl) skipStage(job *domain.Job, onUpdate func(domain.JobEventType), stage domain.JobStage, artifacts ...string) bool {
+	if !job.Checkpoints.Done(stage) {
+		return false
+	}
+	for _, artifact := range artifacts {
+		if artifact == "" || !s.fileRepo.FileExists(artifact) {
+			s.logger.WithFields(logrus.Fields{
+				"jobID":    job.ID,
+				"stage":    stage,
+				"artifact": artifact,
+			}).Warn("ergebnis eines abgeschlossenen Schritts fehlt, führe ihn erneut aus")
+			return false
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"jobID": job.ID,
+		"stage": stage,
+	}).Info("schritt bereits abgeschlossen, überspringe")
+	job.UpdateStageProgress(stage, 1)
+	onUpdate(domain.JobEventStage)
+	return true
+}
+
+// runStage führt einen Schritt der Pipeline mit eigener Zeitbegrenzung aus
+// und übergibt fn eine Funktion, die den Fortschritt des Schritts am Job
+// vermerkt. Läuft die Zeit ab, wird der Fehler als domain.ErrTimeout
+// gekennzeichnet.
+func (s *ConversionServiceImpl) runStage(ctx context.Context, job *domain.Job, onUpdate func(domain.JobEventType), stage domain.JobStage, timeout time.Duration, fn func(ctx context.Context, progress converter.ProgressFunc) error) error {
+	// Spätere Schritte bauen auf dem Ergebnis dieses Schritts auf und müssen
+	// ebenfalls neu ausgeführt werden.
+	job.Checkpoints.Reset(stage)
+	job.UpdateStageProgress(stage, 0)
+	onUpdate(domain.JobEventStage)
+	progress := func(fraction float64) {
+		before := job.Progress
+		job.UpdateStageProgress(stage, fraction)
+		// Nur volle Prozentpunkte melden, ffmpeg liefert deutlich öfter
+		// Fortschritt.
+		if job.Progress != before {
+			onUpdate(domain.JobEventProgress)
+		}
+	}
+
+	if timeout <= 0 {
+		return fn(ctx, progress)
+	}
+
+	stageCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(stageCtx, progress)
+	if err != nil && ctx.Err() == nil && errors.Is(stageCtx.Err(), context.DeadlineExceeded) {
+		s.logger.WithFields(logrus.Fields{
+			"jobID":   job.ID,
+			"stage":   stage,
+			"timeout": timeout,
+		}).Error("zeitüberschreitung im Pipeline-Schritt")
+		return fmt.Errorf("%w: schritt %s nach %s abgebrochen: %w", domain.ErrTimeout, stage, timeout, err)
+	}
+	return err
+}
+
+// rasterSize ermittelt die Pixelgröße der Slide-Bilder aus dem Seitenformat
+// des PDFs. Ist es nicht lesbar, wird ein 16:9-Format angenommen.
+func (s *ConversionServiceImpl) rasterSize(ctx context.Context, job *domain.Job, pdfPath string) (int, int) {
+	frameWidth, frameHeight := job.Config.FrameSize()
+
+	pageWidth, pageHeight, err := s.pdfConverter.GetPageSize(ctx, pdfPath)
+	if err != nil || pageWidth <= 0 || pageHeight <= 0 {
+		s.logger.WithError(err).WithField("jobID", job.ID).Warn("seitengröße nicht lesbar, verwende 16:9")
+		return frameWidth, frameHeight
+	}
+
+	width, height := domain.RasterSize(frameWidth, frameHeight, pageWidth/pageHeight, job.Config.FitMode)
+
+	s.logger.WithFields(logrus.Fields{
+		"jobID":      job.ID,
+		"pageWidth":  pageWidth,
+		"pageHeight": pageHeight,
+		"width":      width,
+		"height":     height,
+	}).Debug("rastergröße ermittelt")
+
+	return width, height
+}
+
+// deckSlides liefert die sichtbaren Slides der PPTX, wenn Inhalte der
+// Präsentation verwendet werden sollen. Sie sind nach Position im Video
+// indiziert; Zeitvorgaben, Übergänge, Kommentare, Notizen und Titel werden
+// darüber den Bildern zugeordnet. Ist die PPTX nicht lesbar oder weicht die
+// Zahl der Slides von der Zahl der Bilder ab, liefen diese versetzt zu den
+// Slides. Dann wird nichts aus der Präsentation übernommen und die
+// konfigurierten Werte gelten.
+func (s *ConversionServiceImpl) deckSlides(job *domain.Job, uploadPath string, imageCount int) []domain.DeckSlide {
+	if !job.Config.ReadsDeck() {
+		return nil
+	}
+
+	deck, err := s.pptxParser.Parse(uploadPath)
+	if err != nil {
+		s.logger.WithError(err).WithField("jobID", job.ID).Warn("Zeitvorgaben der Präsentation nicht lesbar, verwende Konfiguration")
+		return nil
+	}
+
+	slides := deck.VisibleSlides()
+	if len(slides) != imageCount {
+		s.logger.WithFields(logrus.Fields{
+			"jobID":      job.ID,
+			"slideCount": len(slides),
+			"imageCount": imageCount,
+		}).Warn("slides lassen sich den Bildern nicht zuordnen, verwende Konfiguration")
+		return nil
+	}
+
+	return slides
+}
+
+// collectNarrations liefert die Audiospur jeder Slide: aufgezeichnete
+// Kommentare aus der PPTX und, für Slides ohne Kommentar, die vertonten
+// Sprechernotizen. Nicht lesbare Kommentare werden übersprungen.
+func (s *ConversionServiceImpl) collectNarrations(ctx context.Context, job *domain.Job, uploadPath, tempPath string, slides []domain.DeckSlide, progress converter.ProgressFunc) (map[int]domain.Narration, error) {
+	if !job.Config.UseNarration && !job.Config.SpeakNotes {
+		return nil, nil
+	}
+
+	if job.Config.SpeakNotes && s.synthesizer == nil {
+		return nil, domain.ErrTTSNotConfigured
+	}
+
+	narrationDir := filepath.Join(tempPath, "narration")
+	if err := os.MkdirAll(narrationDir, 0755); err != nil {
+		return nil, fmt.Errorf("fehler beim Erstellen des Narration-Verzeichnisses: %w", err)
+	}
+
+	narrations := make(map[int]domain.Narration)
+	for i, slide := range slides {
+		progress(float64(i) / float64(len(slides)))
+
+		if job.Config.UseNarration && slide.Narration != "" {
+			if narration, ok := s.extractNarration(ctx, job, uploadPath, narrationDir, slide); ok {
+				narrations[i] = narration
+				continue
+			}
+		}
+
+		if job.Config.SpeakNotes && slide.Notes != "" {
+			narration, err := s.synthesizeNotes(ctx, job, narrationDir, slide)
+			if err != nil {
+				return nil, err
+			}
+			narrations[i] = narration
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"jobID":          job.ID,
+		"narrationCount": len(narrations),
+	}).Info("kommentare für die Slides ermittelt")
+
+	return narrations, nil
+}
+
+func (s *ConversionServiceImpl) extractNarration(ctx context.Context, job *domain.Job, uploadPath, narrationDir string, slide domain.DeckSlide) (domain.Narration, bool) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"jobID": job.ID,
+		"slide": slide.Number,
+		"part":  slide.Narration,
+	})
+
+	narrationPath := filepath.Join(narrationDir, fmt.Sprintf("slide-%d%s", slide.Number, filepath.Ext(slide.Narration)))
+	if err := s.pptxParser.ExtractPart(uploadPath, slide.Narration, narrationPath); err != nil {
+		logger.WithError(err).Warn("kommentar konnte nicht extrahiert werden")
+		return domain.Narration{}, false
+	}
+
+	duration, err := s.mediaProber.ProbeDuration(ctx, narrationPath)
+	if err != nil {
+		logger.WithError(err).Warn("länge des Kommentars konnte nicht ermittelt werden")
+		return domain.Narration{}, false
+	}
+
+	return domain.Narration{
+		Path:     narrationPath,
+		Duration: duration,
+	}, true
+}
+
+func (s *ConversionServiceImpl) synthesizeNotes(ctx context.Context, job *domain.Job, narrationDir string, slide domain.DeckSlide) (domain.Narration, error) {
+	speechPath := filepath.Join(narrationDir, fmt.Sprintf("slide-%d-tts.wav", slide.Number))
+	if err := s.synthesizer.Synthesize(ctx, slide.Notes, speechPath, job.Config.Voice); err != nil {
+		return domain.Narration{}, fmt.Errorf("slide %d: %w", slide.Number, err)
+	}
+
+	duration, err := s.mediaProber.ProbeDuration(ctx, speechPath)
+	if err != nil {
+		return domain.Narration{}, fmt.Errorf("slide %d: %w", slide.Number, err)
+	}
+
+	return domain.Narration{
+		Path:     speechPath,
+		Duration: duration,
+		Stretch:  true,
+	}, nil
+}
+
+// writeSubtitles erzeugt die Untertitel aus den Sprechernotizen, speichert
+// sie am Job und schreibt sie als SRT-Datei für ffmpeg.
+func (s *ConversionServiceImpl) writeSubtitles(job *domain.Job, tempPath string, timeline domain.Timeline, slides []domain.DeckSlide) (string, error) {
+	notes := make([]string, len(slides))
+	for i, slide := range slides {
+		notes[i] = slide.Notes
+	}
+
+	job.Subtitles = domain.BuildCues(timeline, notes)
+
+	s.logger.WithFields(logrus.Fields{
+		"jobID":    job.ID,
+		"cueCount": len(job.Subtitles),
+	}).Info("untertitel aus Sprechernotizen erzeugt")
+
+	if len(job.Subtitles) == 0 {
+		return "", nil
+	}
+
+	subtitlesPath := filepath.Join(tempPath, "subtitles.srt")
+	if err := os.WriteFile(subtitlesPath, []byte(domain.FormatSRT(job.Subtitles)), 0644); err != nil {
+		return "", fmt.Errorf("fehler beim Schreiben der Untertitel: %w", err)
+	}
+
+	return subtitlesPath, nil
+}
+
+// writeChapters erzeugt Kapitelmarken aus den Slide-Titeln, speichert sie am
+// Job und schreibt sie als ffmetadata-Datei für ffmpeg.
+func (s *ConversionServiceImpl) writeChapters(job *domain.Job, tempPath string, timeline domain.Timeline, slides []domain.DeckSlide) (string, error) {
+	titles := make([]string, len(slides))
+	for i, slide := range slides {
+		titles[i] = slide.Title
+	}
+
+	job.Chapters = domain.BuildChapters(timeline, titles)
+
+	s.logger.WithFields(logrus.Fields{
+		"jobID":        job.ID,
+		"chapterCount": len(job.Chapters),
+	}).Info("kapitelmarken aus Slide-Titeln erzeugt")
+
+	chaptersPath := filepath.Join(tempPath, "chapters.txt")
+	if err := os.WriteFile(chaptersPath, []byte(domain.FormatFFMetadata(job.Chapters)), 0644); err != nil {
+		return "", fmt.Errorf("fehler beim Schreiben der Kapitelmarken: %w", err)
+	}
+
+	return chaptersPath, nil
+}
+
 func (s *ConversionServiceImpl) ValidateDependencies() error {
-	if libreOffice, ok := s.pptxConverter.(*converter.LibreOfficeConverter); ok {
-		if !libreOffice.IsAvailable() {
+	switch pptxConverter := s.pptxConverter.(type) {
+	case *converter.LibreOfficeConverter:
+		if !pptxConverter.IsAvailable() {
 			return fmt.Errorf("LibreOffice ist nicht verfügbar")
 		}
+	case *converter.LibreOfficePool:
+		if !pptxConverter.IsAvailable() {
+			return fmt.Errorf("unoserver ist nicht verfügbar")
+		}
 	}
 
 	if poppler, ok := s.pdfConverter.(*converter.PopplerConverter); ok {
@@ -110,5 +484,16 @@ func (s *ConversionServiceImpl) ValidateDependencies() error {
 		}
 	}
 
+	switch synthesizer := s.synthesizer.(type) {
+	case *converter.EspeakSynthesizer:
+		if !synthesizer.IsAvailable() {
+			return fmt.Errorf("espeak-ng ist nicht verfügbar")
+		}
+	case *converter.PiperSynthesizer:
+		if !synthesizer.IsAvailable() {
+			return fmt.Errorf("piper ist nicht verfügbar")
+		}
+	}
+
 	return nil
 }
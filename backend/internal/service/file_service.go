@@ -8,6 +8,7 @@ import (
 	"pptx2mp4/backend/internal/domain"
 	"pptx2mp4/backend/internal/repository"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -19,10 +20,14 @@ const (
 	AllowedExtension = ".pptx"
 )
 
+var AllowedAudioExtensions = []string{".mp3", ".wav", ".ogg", ".m4a"}
+
 type FileService interface {
 	ValidateUpload(fileHeader *multipart.FileHeader) error
 	SaveUpload(jobID string, fileHeader *multipart.FileHeader) (string, error)
-	GetOutputFile(jobID string) (string, error)
+	ValidateAudioUpload(fileHeader *multipart.FileHeader) error
+	SaveAudioUpload(jobID string, fileHeader *multipart.FileHeader) (string, error)
+	GetOutputFile(jobID string, extension string) (string, error)
 	SanitizeFilename(filename string) string
 	CleanupJob(jobID string) error
 }
@@ -92,8 +97,66 @@ func (s *FileServiceImpl) SaveUpload(jobID string, fileHeader *multipart.FileHea
 	return filePath, nil
 }
 
-func (s *FileServiceImpl) GetOutputFile(jobID string) (string, error) {
-	outputPath := s.fileRepo.GetOutputFilePath(jobID)
+func (s *FileServiceImpl) ValidateAudioUpload(fileHeader *multipart.FileHeader) error {
+	if fileHeader.Size > MaxFileSize {
+		return domain.ErrFileTooLarge
+	}
+
+	if !slices.Contains(AllowedAudioExtensions, strings.ToLower(filepath.Ext(fileHeader.Filename))) {
+		return domain.ErrInvalidExtension
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return fmt.Errorf("fehler beim Öffnen der Datei: %w", err)
+	}
+	defer file.Close()
+
+	buffer := make([]byte, 512)
+	n, err := file.Read(buffer)
+	if err != nil {
+		return fmt.Errorf("fehler beim Lesen der Datei: %w", err)
+	}
+
+	// MP3 ohne ID3-Tag wird nicht erkannt und landet bei application/octet-stream,
+	// M4A wird als video/mp4 erkannt.
+	mimeType := http.DetectContentType(buffer[:n])
+	if !strings.HasPrefix(mimeType, "audio/") &&
+		mimeType != "application/ogg" &&
+		mimeType != "video/mp4" &&
+		mimeType != "application/octet-stream" {
+		return domain.ErrInvalidAudioFile
+	}
+
+	return nil
+}
+
+func (s *FileServiceImpl) SaveAudioUpload(jobID string, fileHeader *multipart.FileHeader) (string, error) {
+	s.logger.WithFields(logrus.Fields{
+		"jobID":    jobID,
+		"filename": fileHeader.Filename,
+		"size":     fileHeader.Size,
+	}).Info("speichere Audio-Upload")
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("fehler beim Öffnen der Datei: %w", err)
+	}
+	defer file.Close()
+
+	extension := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	filePath, err := s.fileRepo.SaveBackgroundAudio(jobID, file, extension)
+	if err != nil {
+		s.logger.WithError(err).Error("fehler beim Speichern der Audiodatei")
+		return "", err
+	}
+
+	s.logger.WithField("filePath", filePath).Info("Audio-Upload erfolgreich gespeichert")
+	return filePath, nil
+}
+
+func (s *FileServiceImpl) GetOutputFile(jobID string, extension string) (string, error) {
+	outputPath := s.fileRepo.GetOutputFilePath(jobID, extension)
 
 	if !s.fileRepo.FileExists(outputPath) {
 		return "", domain.ErrFileNotFound
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"pptx2mp4/backend/internal/domain"
+	"pptx2mp4/backend/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JanitorOptions legt fest, wie oft aufgeräumt wird und wie lange Jobs und
+// Dateien je Zustand aufbewahrt werden. Eine TTL <= 0 schaltet das Aufräumen
+// für diesen Zustand ab.
+type JanitorOptions struct {
+	Interval time.Duration
+	// FailedJobTTL gilt für fehlgeschlagene und abgebrochene Jobs.
+	FailedJobTTL time.Duration
+	// CompletedJobTTL gilt für abgeschlossene Jobs, deren Ergebnis nie
+	// heruntergeladen wurde.
+	CompletedJobTTL time.Duration
+	// DownloadedJobTTL gilt für heruntergeladene Jobs, gerechnet ab dem
+	// Download. Ihre Dateien sind dann bereits gelöscht.
+	DownloadedJobTTL time.Duration
+	// OrphanTTL gilt für Job-Verzeichnisse ohne zugehörigen Job.
+	OrphanTTL time.Duration
+}
+
+// Janitor löscht in regelmäßigen Abständen beendete Jobs samt ihrer Dateien
+// sowie verwaiste Job-Verzeichnisse, deren Aufbewahrungszeit abgelaufen ist.
+// Wartende und laufende Jobs werden nie angefasst; Jobs werden über den
+// JobService gelöscht, damit ihr Zustand beim Löschen erneut geprüft wird.
+type Janitor struct {
+	jobService JobService
+	fileRepo   repository.FileRepository
+	options    JanitorOptions
+	logger     *logrus.Logger
+}
+
+func NewJanitor(
+	jobService JobService,
+	fileRepo repository.FileRepository,
+	options JanitorOptions,
+	logger *logrus.Logger,
+) *Janitor {
+	return &Janitor{
+		jobService: jobService,
+		fileRepo:   fileRepo,
+		options:    options,
+		logger:     logger,
+	}
+}
+
+// Start räumt sofort und danach alle Interval auf, bis ctx beendet wird.
+func (j *Janitor) Start(ctx context.Context) {
+	if j.options.Interval <= 0 {
+		j.logger.Info("aufräumen deaktiviert")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(j.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			j.Sweep()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	j.logger.WithFields(logrus.Fields{
+		"interval":         j.options.Interval,
+		"failedJobTTL":     j.options.FailedJobTTL,
+		"completedJobTTL":  j.options.CompletedJobTTL,
+		"downloadedJobTTL": j.options.DownloadedJobTTL,
+		"orphanTTL":        j.options.OrphanTTL,
+	}).Info("aufräumen gestartet")
+}
+
+// Sweep führt einen Aufräumdurchlauf aus.
+func (j *Janitor) Sweep() {
+	now := time.Now()
+
+	jobs, err := j.jobService.GetAllJobs()
+	if err != nil {
+		j.logger.WithError(err).Error("fehler beim Lesen der Jobs")
+		return
+	}
+
+	known := make(map[string]bool, len(jobs))
+	removedJobs, removedOrphans := 0, 0
+	var reclaimed int64
+
+	for _, job := range jobs {
+		known[job.ID] = true
+		if !j.expired(job, now) {
+			continue
+		}
+
+		usage, err := j.fileRepo.GetJobUsage(job.ID)
+		if err != nil {
+			j.logger.WithError(err).WithField("jobID", job.ID).Warn("fehler beim Lesen der Job-Dateien")
+		}
+		// Der Job kann sich seit dem Lesen geändert haben, etwa durch einen
+		// erneuten Versuch. RemoveExpired prüft ihn daher noch einmal.
+		removed, err := j.jobService.RemoveExpired(job.ID, func(job *domain.Job) bool {
+			return j.expired(job, now)
+		})
+		if err != nil {
+			j.logger.WithError(err).WithField("jobID", job.ID).Warn("fehler beim Löschen des Jobs")
+			continue
+		}
+		if !removed {
+			continue
+		}
+
+		j.logger.WithFields(logrus.Fields{
+			"jobID":  job.ID,
+			"status": job.Status,
+			"bytes":  usage.Size,
+		}).Info("abgelaufener Job gelöscht")
+		removedJobs++
+		reclaimed += usage.Size
+	}
+
+	if j.options.OrphanTTL > 0 {
+		jobIDs, err := j.fileRepo.ListJobIDs()
+		if err != nil {
+			j.logger.WithError(err).Error("fehler beim Lesen der Job-Verzeichnisse")
+			jobIDs = nil
+		}
+
+		for _, jobID := range jobIDs {
+			if known[jobID] {
+				continue
+			}
+
+			usage, err := j.fileRepo.GetJobUsage(jobID)
+			if err != nil {
+				j.logger.WithError(err).WithField("jobID", jobID).Warn("fehler beim Lesen der Job-Dateien")
+				continue
+			}
+			// Ein Upload speichert seine Dateien, bevor der Job angelegt
+			// wird. Jüngere Verzeichnisse bleiben daher bestehen.
+			if now.Sub(usage.ModTime) < j.options.OrphanTTL {
+				continue
+			}
+			if _, err := j.jobService.GetJob(jobID); err == nil {
+				continue
+			}
+
+			if err := j.fileRepo.CleanupJob(jobID); err != nil {
+				j.logger.WithError(err).WithField("jobID", jobID).Warn("fehler beim Bereinigen der Job-Dateien")
+				continue
+			}
+
+			j.logger.WithFields(logrus.Fields{
+				"jobID": jobID,
+				"bytes": usage.Size,
+			}).Info("verwaiste Job-Dateien gelöscht")
+			removedOrphans++
+			reclaimed += usage.Size
+		}
+	}
+
+	fields := logrus.Fields{
+		"jobs":     removedJobs,
+		"orphans":  removedOrphans,
+		"bytes":    reclaimed,
+		"duration": time.Since(now).Round(time.Millisecond),
+	}
+	if removedJobs > 0 || removedOrphans > 0 {
+		j.logger.WithFields(fields).Info("speicher bereinigt")
+	} else {
+		j.logger.WithFields(fields).Debug("nichts zu bereinigen")
+	}
+}
+
+// expired gibt an, ob die Aufbewahrungszeit eines beendeten Jobs abgelaufen
+// ist. Maßgeblich ist das Ende der Verarbeitung, bei heruntergeladenen Jobs
+// der Download.
+func (j *Janitor) expired(job *domain.Job, now time.Time) bool {
+	finishedAt := job.UpdatedAt
+	if job.CompletedAt != nil {
+		finishedAt = *job.CompletedAt
+	}
+
+	var ttl time.Duration
+	switch job.Status {
+	case domain.JobStatusFailed, domain.JobStatusCancelled:
+		ttl = j.options.FailedJobTTL
+	case domain.JobStatusCompleted:
+		ttl = j.options.CompletedJobTTL
+		if job.DownloadedAt != nil {
+			ttl = j.options.DownloadedJobTTL
+			finishedAt = *job.DownloadedAt
+		}
+	default:
+		return false
+	}
+	if ttl <= 0 {
+		return false
+	}
+	return now.Sub(finishedAt) >= ttl
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"pptx2mp4/backend/internal/domain"
+	"pptx2mp4/backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestJanitorExpired(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	options := JanitorOptions{
+		FailedJobTTL:     24 * time.Hour,
+		CompletedJobTTL:  48 * time.Hour,
+		DownloadedJobTTL: time.Hour,
+	}
+
+	tests := []struct {
+		name    string
+		options JanitorOptions
+		job     domain.Job
+		want    bool
+	}{
+		{
+			name: "wartend",
+			job:  domain.Job{Status: domain.JobStatusPending, UpdatedAt: *ago(100 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "laufend",
+			job:  domain.Job{Status: domain.JobStatusProcessing, UpdatedAt: *ago(100 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "fehlgeschlagen vor ablauf",
+			job:  domain.Job{Status: domain.JobStatusFailed, CompletedAt: ago(23 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "fehlgeschlagen nach ablauf",
+			job:  domain.Job{Status: domain.JobStatusFailed, CompletedAt: ago(24 * time.Hour)},
+			want: true,
+		},
+		{
+			name: "abgebrochen nach ablauf",
+			job:  domain.Job{Status: domain.JobStatusCancelled, CompletedAt: ago(25 * time.Hour)},
+			want: true,
+		},
+		{
+			name: "ohne ende zählt die letzte änderung",
+			job:  domain.Job{Status: domain.JobStatusFailed, UpdatedAt: *ago(25 * time.Hour)},
+			want: true,
+		},
+		{
+			name: "abgeschlossen, nicht heruntergeladen, vor ablauf",
+			job:  domain.Job{Status: domain.JobStatusCompleted, CompletedAt: ago(47 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "abgeschlossen, nicht heruntergeladen, nach ablauf",
+			job:  domain.Job{Status: domain.JobStatusCompleted, CompletedAt: ago(48 * time.Hour)},
+			want: true,
+		},
+		{
+			name: "heruntergeladen zählt ab dem download",
+			job:  domain.Job{Status: domain.JobStatusCompleted, CompletedAt: ago(72 * time.Hour), DownloadedAt: ago(30 * time.Minute)},
+			want: false,
+		},
+		{
+			name: "heruntergeladen nach ablauf",
+			job:  domain.Job{Status: domain.JobStatusCompleted, CompletedAt: ago(2 * time.Hour), DownloadedAt: ago(time.Hour)},
+			want: true,
+		},
+		{
+			name:    "ttl 0 schaltet das löschen ab",
+			options: JanitorOptions{FailedJobTTL: 0, CompletedJobTTL: 48 * time.Hour},
+			job:     domain.Job{Status: domain.JobStatusFailed, CompletedAt: ago(1000 * time.Hour)},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := options
+			if tt.options != (JanitorOptions{}) {
+				opts = tt.options
+			}
+			janitor := &Janitor{options: opts}
+			if got := janitor.expired(&tt.job, now); got != tt.want {
+				t.Errorf("expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJanitorSweep(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	basePath := t.TempDir()
+	jobRepo := repository.NewInMemoryJobRepository()
+	fileRepo := repository.NewFileSystemRepository(basePath)
+	jobService := NewJobService(jobRepo, fileRepo, nil, JobServiceOptions{Workers: 1, MaxQueueLength: 10}, logger)
+	janitor := NewJanitor(jobService, fileRepo, JanitorOptions{
+		FailedJobTTL:     time.Hour,
+		CompletedJobTTL:  time.Hour,
+		DownloadedJobTTL: time.Hour,
+		OrphanTTL:        time.Hour,
+	}, logger)
+
+	old := time.Now().Add(-2 * time.Hour)
+	newJob := func(status domain.JobStatus) *domain.Job {
+		job := domain.NewJob("deck.pptx", &domain.ConversionConfig{})
+		job.Status = status
+		job.UpdatedAt = old
+		job.CompletedAt = &old
+		if err := jobRepo.Create(job); err != nil {
+			t.Fatal(err)
+		}
+		writeJobFile(t, fileRepo.GetUploadPath(job.ID), old)
+		return job
+	}
+
+	failed := newJob(domain.JobStatusFailed)
+	completed := newJob(domain.JobStatusCompleted)
+	pending := newJob(domain.JobStatusPending)
+
+	// Ein erneuter Versuch vor dem Aufräumen macht den Job wieder wartend.
+	retried := newJob(domain.JobStatusFailed)
+	if _, err := jobService.RetryJob(retried.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	recentlyDownloaded := newJob(domain.JobStatusCompleted)
+	if _, err := jobService.MarkDownloaded(recentlyDownloaded.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	orphanDir := filepath.Join(basePath, "temp", "verwaist")
+	writeJobFile(t, orphanDir, old)
+	recentOrphanDir := filepath.Join(basePath, "temp", "neu")
+	writeJobFile(t, recentOrphanDir, time.Now())
+
+	janitor.Sweep()
+
+	for _, job := range []*domain.Job{failed, completed} {
+		if _, err := jobRepo.FindByID(job.ID); !errors.Is(err, domain.ErrJobNotFound) {
+			t.Errorf("abgelaufener Job %s nicht gelöscht", job.Status)
+		}
+		if _, err := os.Stat(fileRepo.GetUploadPath(job.ID)); !os.IsNotExist(err) {
+			t.Errorf("dateien des abgelaufenen Jobs %s nicht gelöscht", job.Status)
+		}
+	}
+
+	for _, job := range []*domain.Job{pending, retried, recentlyDownloaded} {
+		if _, err := jobRepo.FindByID(job.ID); err != nil {
+			t.Errorf("job %s gelöscht: %v", job.ID, err)
+		}
+		if _, err := os.Stat(fileRepo.GetUploadPath(job.ID)); err != nil {
+			t.Errorf("dateien von Job %s gelöscht: %v", job.ID, err)
+		}
+	}
+
+	if _, err := os.Stat(orphanDir); !os.IsNotExist(err) {
+		t.Error("verwaistes Verzeichnis nicht gelöscht")
+	}
+	if _, err := os.Stat(recentOrphanDir); err != nil {
+		t.Errorf("junges Verzeichnis ohne Job gelöscht: %v", err)
+	}
+}
+
+// writeJobFile legt eine Datei in dir an und setzt ihren Zeitstempel auf
+// modTime.
+func writeJobFile(t *testing.T, dir string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "deck.pptx")
+	if err := os.WriteFile(path, []byte("pptx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{path, dir} {
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
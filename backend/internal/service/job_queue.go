@@ -0,0 +1,97 @@
+package service
+
+import (
+	"pptx2mp4/backend/internal/domain"
+	"slices"
+	"sync"
+)
+
+// jobQueue ist eine FIFO-Warteschlange für Job-IDs mit begrenzter Länge.
+// pop blockiert, bis ein Job verfügbar ist oder die Warteschlange mit close
+// geschlossen wird.
+type jobQueue struct {
+	ids       []string
+	maxLength int
+	closed    bool
+	mu        sync.Mutex
+	available *sync.Cond
+}
+
+func newJobQueue(maxLength int) *jobQueue {
+	q := &jobQueue{maxLength: maxLength}
+	q.available = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *jobQueue) push(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.ids) >= q.maxLength {
+		return domain.ErrQueueFull
+	}
+	q.ids = append(q.ids, jobID)
+	q.available.Signal()
+	return nil
+}
+
+// requeue reiht einen bereits bestehenden Job unabhängig von der
+// Begrenzung ein, etwa nach einem Neustart.
+func (q *jobQueue) requeue(jobID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.ids = append(q.ids, jobID)
+	q.available.Signal()
+}
+
+// pop entnimmt den ältesten Job und liefert die IDs der danach noch
+// wartenden Jobs, deren Position sich damit geändert hat. Ist die
+// Warteschlange geschlossen, liefert pop false.
+func (q *jobQueue) pop() (string, []string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.ids) == 0 && !q.closed {
+		q.available.Wait()
+	}
+	if q.closed {
+		return "", nil, false
+	}
+	jobID := q.ids[0]
+	q.ids = slices.Delete(q.ids, 0, 1)
+	return jobID, slices.Clone(q.ids), true
+}
+
+// close weckt alle wartenden pop-Aufrufe. Noch eingereihte Jobs bleiben
+// wartend und werden nach einem Neustart von RecoverJobs neu eingereiht.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.available.Broadcast()
+}
+
+// remove entfernt einen wartenden Job und liefert die IDs der Jobs, die
+// dadurch aufrücken.
+func (q *jobQueue) remove(jobID string) (bool, []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := slices.Index(q.ids, jobID)
+	if index < 0 {
+		return false, nil
+	}
+	q.ids = slices.Delete(q.ids, index, index+1)
+	return true, slices.Clone(q.ids[index:])
+}
+
+// position liefert die 1-basierte Position eines wartenden Jobs.
+func (q *jobQueue) position(jobID string) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := slices.Index(q.ids, jobID)
+	return index + 1, index >= 0
+}
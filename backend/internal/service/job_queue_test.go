@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"pptx2mp4/backend/internal/domain"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJobQueue(t *testing.T) {
+	type step struct {
+		op      string
+		jobID   string
+		wantErr error
+		wantOK  bool
+		// want ist bei pop der entnommene Job, bei remove nicht belegt.
+		want string
+		// moved sind die Jobs, deren Position sich geändert hat.
+		moved []string
+	}
+
+	tests := []struct {
+		name      string
+		maxLength int
+		steps     []step
+		// queued ist der Inhalt der Warteschlange nach allen Schritten.
+		queued []string
+	}{
+		{
+			name:      "fifo",
+			maxLength: 3,
+			steps: []step{
+				{op: "push", jobID: "a"},
+				{op: "push", jobID: "b"},
+				{op: "push", jobID: "c"},
+				{op: "pop", wantOK: true, want: "a", moved: []string{"b", "c"}},
+				{op: "pop", wantOK: true, want: "b", moved: []string{"c"}},
+			},
+			queued: []string{"c"},
+		},
+		{
+			name:      "push bei voller warteschlange",
+			maxLength: 2,
+			steps: []step{
+				{op: "push", jobID: "a"},
+				{op: "push", jobID: "b"},
+				{op: "push", jobID: "c", wantErr: domain.ErrQueueFull},
+				{op: "pop", wantOK: true, want: "a", moved: []string{"b"}},
+				{op: "push", jobID: "c"},
+			},
+			queued: []string{"b", "c"},
+		},
+		{
+			name:      "requeue ignoriert die begrenzung",
+			maxLength: 1,
+			steps: []step{
+				{op: "requeue", jobID: "a"},
+				{op: "requeue", jobID: "b"},
+				{op: "requeue", jobID: "c"},
+				{op: "push", jobID: "d", wantErr: domain.ErrQueueFull},
+			},
+			queued: []string{"a", "b", "c"},
+		},
+		{
+			name:      "remove lässt nachfolgende aufrücken",
+			maxLength: 5,
+			steps: []step{
+				{op: "push", jobID: "a"},
+				{op: "push", jobID: "b"},
+				{op: "push", jobID: "c"},
+				{op: "push", jobID: "d"},
+				{op: "remove", jobID: "b", wantOK: true, moved: []string{"c", "d"}},
+				{op: "remove", jobID: "d", wantOK: true},
+				{op: "remove", jobID: "x"},
+			},
+			queued: []string{"a", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newJobQueue(tt.maxLength)
+
+			for i, s := range tt.steps {
+				switch s.op {
+				case "push":
+					if err := q.push(s.jobID); !errors.Is(err, s.wantErr) {
+						t.Fatalf("schritt %d: push(%q) = %v, want %v", i+1, s.jobID, err, s.wantErr)
+					}
+				case "requeue":
+					q.requeue(s.jobID)
+				case "pop":
+					jobID, moved, ok := q.pop()
+					if ok != s.wantOK || jobID != s.want || !slices.Equal(moved, s.moved) {
+						t.Fatalf("schritt %d: pop() = %q, %q, %v, want %q, %q, %v", i+1, jobID, moved, ok, s.want, s.moved, s.wantOK)
+					}
+				case "remove":
+					ok, moved := q.remove(s.jobID)
+					if ok != s.wantOK || !slices.Equal(moved, s.moved) {
+						t.Fatalf("schritt %d: remove(%q) = %v, %q, want %v, %q", i+1, s.jobID, ok, moved, s.wantOK, s.moved)
+					}
+				}
+			}
+
+			for i, jobID := range tt.queued {
+				if position, ok := q.position(jobID); !ok || position != i+1 {
+					t.Errorf("position(%q) = %d, %v, want %d", jobID, position, ok, i+1)
+				}
+			}
+			if _, ok := q.position("unbekannt"); ok {
+				t.Error("position eines unbekannten Jobs gefunden")
+			}
+		})
+	}
+}
+
+func TestJobQueuePopWaitsForJob(t *testing.T) {
+	q := newJobQueue(1)
+
+	popped := make(chan string)
+	go func() {
+		jobID, _, _ := q.pop()
+		popped <- jobID
+	}()
+
+	select {
+	case jobID := <-popped:
+		t.Fatalf("pop() lieferte %q ohne wartenden Job", jobID)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := q.push("a"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case jobID := <-popped:
+		if jobID != "a" {
+			t.Errorf("pop() = %q, want a", jobID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pop() kehrt nach push nicht zurück")
+	}
+}
+
+func TestJobQueueCloseWakesPop(t *testing.T) {
+	q := newJobQueue(1)
+
+	done := make(chan bool)
+	go func() {
+		_, _, ok := q.pop()
+		done <- ok
+	}()
+
+	q.close()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("pop() nach close lieferte true")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pop() kehrt nach close nicht zurück")
+	}
+
+	if err := q.push("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := q.pop(); ok {
+		t.Error("pop() auf geschlossener Warteschlange lieferte true")
+	}
+}
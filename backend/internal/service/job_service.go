@@ -1,8 +1,14 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"pptx2mp4/backend/internal/domain"
 	"pptx2mp4/backend/internal/repository"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -10,26 +16,83 @@ import (
 type JobService interface {
 	CreateJob(job *domain.Job) error
 	GetJob(jobID string) (*domain.Job, error)
-	UpdateJob(job *domain.Job) error
-	ProcessJob(jobID string) error
+	// UpdateJob ändert den gespeicherten Job mit fn. Lesen, Ändern und
+	// Speichern geschehen atomar gegenüber allen anderen Änderungen.
+	UpdateJob(jobID string, fn func(job *domain.Job) error) (*domain.Job, error)
+	// SubmitJob legt einen neuen Job an und reiht ihn ein. Ist die
+	// Warteschlange voll, wird der Job samt seiner hochgeladenen Dateien
+	// verworfen und domain.ErrQueueFull geliefert.
+	SubmitJob(job *domain.Job) error
+	QueuePosition(jobID string) (int, bool)
+	ProcessJob(ctx context.Context, jobID string) error
+	CancelJob(jobID string) (*domain.Job, error)
+	// RetryJob reiht einen fehlgeschlagenen Job erneut ein. Bereits
+	// abgeschlossene Schritte werden dabei übersprungen.
+	RetryJob(jobID string) (*domain.Job, error)
+	// MarkDownloaded hält fest, dass das Ergebnis eines abgeschlossenen Jobs
+	// heruntergeladen wurde.
+	MarkDownloaded(jobID string) (*domain.Job, error)
+	// RemoveExpired löscht einen beendeten Job samt seiner Dateien, wenn
+	// expired für seinen aktuellen Zustand zutrifft, und meldet, ob er
+	// gelöscht wurde.
+	RemoveExpired(jobID string, expired func(job *domain.Job) bool) (bool, error)
+	// Subscribe liefert die Ereignisse eines Jobs, beginnend mit seinem
+	// aktuellen Zustand. Die zurückgegebene Funktion beendet das Abonnement.
+	Subscribe(jobID string) (<-chan domain.JobEvent, func(), error)
 	GetAllJobs() ([]*domain.Job, error)
 }
 
+// JobServiceOptions legt fest, wie viele Jobs gleichzeitig laufen und
+// warten dürfen.
+type JobServiceOptions struct {
+	// Workers ist die Zahl gleichzeitig verarbeiteter Jobs.
+	Workers int
+	// MaxQueueLength begrenzt die Zahl wartender Jobs.
+	MaxQueueLength int
+	// JobTimeout begrenzt die Gesamtlaufzeit eines Jobs, <= 0 bedeutet
+	// keine Begrenzung.
+	JobTimeout time.Duration
+}
+
+// maxJobAttempts begrenzt, wie oft ein durch Neustart unterbrochener Job
+// automatisch fortgesetzt wird. Bricht ein Job den Server wiederholt ab,
+// schlägt er danach fehl.
+const maxJobAttempts = 3
+
 type JobServiceImpl struct {
 	jobRepo           repository.JobRepository
+	fileRepo          repository.FileRepository
 	conversionService ConversionService
+	options           JobServiceOptions
+	queue             *jobQueue
 	logger            *logrus.Logger
+
+	// running enthält die Abbruchfunktionen der laufenden Jobs. mu schützt
+	// außerdem jede Änderung an gespeicherten Jobs, siehe UpdateJob.
+	running map[string]context.CancelCauseFunc
+	mu      sync.Mutex
+
+	// subscribers enthält je Job die Kanäle der Event-Streams.
+	subscribers map[string]map[chan domain.JobEvent]struct{}
+	subMu       sync.Mutex
 }
 
 func NewJobService(
 	jobRepo repository.JobRepository,
+	fileRepo repository.FileRepository,
 	conversionService ConversionService,
+	options JobServiceOptions,
 	logger *logrus.Logger,
 ) *JobServiceImpl {
 	return &JobServiceImpl{
 		jobRepo:           jobRepo,
+		fileRepo:          fileRepo,
 		conversionService: conversionService,
+		options:           options,
+		queue:             newJobQueue(options.MaxQueueLength),
 		logger:            logger,
+		running:           make(map[string]context.CancelCauseFunc),
+		subscribers:       make(map[string]map[chan domain.JobEvent]struct{}),
 	}
 }
 
@@ -49,43 +112,433 @@ func (s *JobServiceImpl) GetJob(jobID string) (*domain.Job, error) {
 	return s.jobRepo.FindByID(jobID)
 }
 
-func (s *JobServiceImpl) UpdateJob(job *domain.Job) error {
-	return s.jobRepo.Update(job)
+// UpdateJob liest den gespeicherten Job, ändert ihn mit fn und speichert ihn
+// wieder, alles unter s.mu. So kann keine andere Änderung dazwischen
+// geschrieben werden. Liefert fn einen Fehler, wird nichts gespeichert;
+// der gelesene Job wird trotzdem zurückgegeben.
+func (s *JobServiceImpl) UpdateJob(jobID string, fn func(job *domain.Job) error) (*domain.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.jobRepo.FindByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(job); err != nil {
+		return job, err
+	}
+	if err := s.jobRepo.Update(job); err != nil {
+		return job, err
+	}
+	return job, nil
 }
 
-func (s *JobServiceImpl) ProcessJob(jobID string) error {
-	s.logger.WithField("jobID", jobID).Info("starte Job-Verarbeitung")
+// Start startet die Worker, die Jobs in der Reihenfolge ihres Eingangs aus
+// der Warteschlange verarbeiten. Wird ctx beendet, nehmen die Worker keine
+// neuen Jobs mehr an.
+func (s *JobServiceImpl) Start(ctx context.Context) {
+	for range max(s.options.Workers, 1) {
+		go s.work(ctx)
+	}
+	go func() {
+		<-ctx.Done()
+		s.queue.close()
+	}()
+	s.logger.WithFields(logrus.Fields{
+		"workers":        s.options.Workers,
+		"maxQueueLength": s.options.MaxQueueLength,
+	}).Info("job-worker gestartet")
+}
 
-	job, err := s.jobRepo.FindByID(jobID)
+// RecoverJobs bringt die Jobs aus einem früheren Lauf in einen gültigen
+// Zustand: Jobs, deren Verarbeitung unterbrochen wurde, werden ab dem letzten
+// abgeschlossenen Schritt fortgesetzt, nach maxJobAttempts Versuchen schlagen
+// sie fehl. Wartende Jobs werden in der ursprünglichen Reihenfolge neu
+// eingereiht, auch wenn es mehr als MaxQueueLength sind; die Begrenzung gilt
+// nur für neue Uploads. Muss vor Start aufgerufen werden.
+func (s *JobServiceImpl) RecoverJobs() error {
+	jobs, err := s.jobRepo.FindAll()
 	if err != nil {
-		s.logger.WithError(err).Error("Job nicht gefunden")
 		return err
 	}
+	slices.SortFunc(jobs, func(a, b *domain.Job) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
 
-	job.UpdateStatus(domain.JobStatusProcessing)
-	if err := s.jobRepo.Update(job); err != nil {
-		s.logger.WithError(err).Error("fehler beim Aktualisieren des Job-Status")
+	interrupted, requeued := 0, 0
+	for _, job := range jobs {
+		switch job.Status {
+		case domain.JobStatusProcessing:
+			if job.Attempts >= maxJobAttempts {
+				job.SetError(domain.ErrJobInterrupted)
+				if err := s.jobRepo.Update(job); err != nil {
+					return err
+				}
+				interrupted++
+				continue
+			}
+
+			job.Reset()
+			if err := s.jobRepo.Update(job); err != nil {
+				return err
+			}
+			s.queue.requeue(job.ID)
+			s.logger.WithFields(logrus.Fields{
+				"jobID":     job.ID,
+				"attempts":  job.Attempts,
+				"completed": job.Checkpoints.Completed,
+			}).Info("unterbrochener Job wird fortgesetzt")
+			requeued++
+		case domain.JobStatusPending:
+			s.queue.requeue(job.ID)
+			requeued++
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"jobCount":    len(jobs),
+		"interrupted": interrupted,
+		"requeued":    requeued,
+	}).Info("gespeicherte Jobs wiederhergestellt")
+	return nil
+}
+
+func (s *JobServiceImpl) work(ctx context.Context) {
+	for {
+		jobID, waiting, ok := s.queue.pop()
+		if !ok {
+			return
+		}
+		s.publishPositions(waiting)
+
+		if err := s.ProcessJob(ctx, jobID); err != nil && !errors.Is(err, domain.ErrJobCancelled) {
+			s.logger.WithError(err).WithField("jobID", jobID).Error("Job-Verarbeitung fehlgeschlagen")
+		}
+	}
+}
+
+func (s *JobServiceImpl) SubmitJob(job *domain.Job) error {
+	if err := s.CreateJob(job); err != nil {
 		return err
 	}
 
-	if err := s.conversionService.Convert(job); err != nil {
-		s.logger.WithError(err).Error("konvertierung fehlgeschlagen")
-		job.SetError(err)
-		s.jobRepo.Update(job)
+	if err := s.queue.push(job.ID); err != nil {
+		// Der Job wurde eben erst angelegt und ist noch niemandem bekannt.
+		s.logger.WithField("jobID", job.ID).Warn("warteschlange voll, neuer Job wird verworfen")
+		if err := s.jobRepo.Delete(job.ID); err != nil {
+			s.logger.WithError(err).WithField("jobID", job.ID).Warn("fehler beim Löschen des Jobs")
+		}
+		s.cleanupFiles(job.ID)
 		return err
 	}
 
-	job.UpdateStatus(domain.JobStatusCompleted)
-	job.UpdateProgress(100)
-	if err := s.jobRepo.Update(job); err != nil {
+	s.logger.WithField("jobID", job.ID).Info("Job in Warteschlange eingereiht")
+	return nil
+}
+
+func (s *JobServiceImpl) QueuePosition(jobID string) (int, bool) {
+	return s.queue.position(jobID)
+}
+
+func (s *JobServiceImpl) ProcessJob(ctx context.Context, jobID string) error {
+	s.logger.WithField("jobID", jobID).Info("starte Job-Verarbeitung")
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// job ist die Arbeitskopie der Pipeline. Nur dieser Worker ändert sie;
+	// Fortschritt und Ergebnisse werden über UpdateJob in den gespeicherten
+	// Job übernommen.
+	job, err := s.UpdateJob(jobID, func(job *domain.Job) error {
+		if job.IsCancelled() {
+			return domain.ErrJobCancelled
+		}
+		s.running[jobID] = cancel
+		job.Attempts++
+		job.UpdateStatus(domain.JobStatusProcessing)
+		return nil
+	})
+	if errors.Is(err, domain.ErrJobCancelled) {
+		s.logger.WithField("jobID", jobID).Info("Job wurde vor dem Start abgebrochen")
+		return err
+	}
+	if job != nil {
+		defer s.unregister(jobID)
+	}
+	if err != nil {
 		s.logger.WithError(err).Error("fehler beim Aktualisieren des Job-Status")
 		return err
 	}
+	s.publish(jobID, domain.JobEventStatus)
+
+	if s.options.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.JobTimeout)
+		defer cancel()
+	}
+
+	err = s.conversionService.Convert(ctx, job, func(eventType domain.JobEventType) {
+		// Jede Änderung wird gespeichert, bei einem neuen Schritt also auch
+		// die Checkpoints der bisherigen, damit ein Neustart dort fortsetzen
+		// kann.
+		_, err := s.UpdateJob(jobID, func(stored *domain.Job) error {
+			if stored.IsCancelled() {
+				return domain.ErrJobCancelled
+			}
+			stored.SyncProgress(job)
+			return nil
+		})
+		if err != nil {
+			if !errors.Is(err, domain.ErrJobCancelled) {
+				s.logger.WithError(err).WithField("jobID", jobID).Warn("fehler beim Speichern des Fortschritts")
+			}
+			return
+		}
+		s.publish(jobID, eventType)
+	})
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, domain.ErrTimeout) {
+		err = fmt.Errorf("%w: job nach %s abgebrochen: %w", domain.ErrTimeout, s.options.JobTimeout, err)
+	}
+
+	// Der Endstatus wird in einem Schritt mit der Prüfung auf Abbruch
+	// gesetzt, damit ein gleichzeitiger Abbruch ihn nicht überschreibt und
+	// umgekehrt.
+	cancelled := false
+	_, updateErr := s.UpdateJob(jobID, func(stored *domain.Job) error {
+		if stored.IsCancelled() {
+			cancelled = true
+			return nil
+		}
+		stored.SyncProgress(job)
+		if err != nil {
+			stored.SetError(err)
+		} else {
+			stored.UpdateStatus(domain.JobStatusCompleted)
+			stored.UpdateProgress(100)
+		}
+		return nil
+	})
+	s.publish(jobID, domain.JobEventStatus)
+
+	if cancelled {
+		// Erst jetzt sind alle Prozesse des Jobs beendet.
+		s.logger.WithField("jobID", jobID).Info("Job-Verarbeitung abgebrochen")
+		s.cleanupFiles(jobID)
+		return domain.ErrJobCancelled
+	}
+	if err != nil {
+		s.logger.WithError(err).Error("konvertierung fehlgeschlagen")
+		return err
+	}
+	if updateErr != nil {
+		s.logger.WithError(updateErr).Error("fehler beim Aktualisieren des Job-Status")
+		return updateErr
+	}
 
 	s.logger.WithField("jobID", jobID).Info("Job-Verarbeitung erfolgreich abgeschlossen")
 	return nil
 }
 
+// CancelJob bricht einen wartenden oder laufenden Job ab. Laufende externe
+// Programme werden beendet; die Dateien des Jobs werden gelöscht, sobald
+// keine Prozesse mehr darauf zugreifen.
+func (s *JobServiceImpl) CancelJob(jobID string) (*domain.Job, error) {
+	var (
+		cancel  context.CancelCauseFunc
+		running bool
+	)
+	job, err := s.UpdateJob(jobID, func(job *domain.Job) error {
+		if !job.IsProcessing() {
+			return fmt.Errorf("%w: %s", domain.ErrInvalidJobStatus, job.Status)
+		}
+		job.UpdateStatus(domain.JobStatusCancelled)
+		cancel, running = s.running[jobID]
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrJobNotFound) && !errors.Is(err, domain.ErrInvalidJobStatus) {
+			s.logger.WithError(err).Error("fehler beim Aktualisieren des Job-Status")
+		}
+		return job, err
+	}
+	s.publish(jobID, domain.JobEventStatus)
+
+	s.logger.WithFields(logrus.Fields{
+		"jobID":   jobID,
+		"running": running,
+	}).Info("Job abgebrochen")
+
+	if running {
+		cancel(domain.ErrJobCancelled)
+	} else {
+		if removed, waiting := s.queue.remove(jobID); removed {
+			s.publishPositions(waiting)
+		}
+		s.cleanupFiles(jobID)
+	}
+
+	return job, nil
+}
+
+// RetryJob setzt einen fehlgeschlagenen Job zurück und reiht ihn wieder ein.
+// Anders als bei SubmitJob bleibt der Job bei voller Warteschlange
+// unverändert erhalten und kann später erneut versucht werden.
+func (s *JobServiceImpl) RetryJob(jobID string) (*domain.Job, error) {
+	// Der Job wird unter s.mu eingereiht; ein Worker kann ihn daher erst
+	// übernehmen, wenn er als wartend gespeichert ist.
+	job, err := s.UpdateJob(jobID, func(job *domain.Job) error {
+		if !job.IsFailed() {
+			return fmt.Errorf("%w: %s", domain.ErrInvalidJobStatus, job.Status)
+		}
+		if err := s.queue.push(jobID); err != nil {
+			return err
+		}
+		job.Reset()
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrQueueFull) {
+			s.logger.WithField("jobID", jobID).Warn("warteschlange voll, Job kann nicht wiederholt werden")
+		} else if !errors.Is(err, domain.ErrJobNotFound) && !errors.Is(err, domain.ErrInvalidJobStatus) {
+			// Der Job wurde eingereiht, aber nicht als wartend gespeichert.
+			s.queue.remove(jobID)
+			s.logger.WithError(err).Error("fehler beim Aktualisieren des Job-Status")
+		}
+		return job, err
+	}
+	s.publish(jobID, domain.JobEventStatus)
+
+	s.logger.WithFields(logrus.Fields{
+		"jobID":     jobID,
+		"attempts":  job.Attempts,
+		"completed": job.Checkpoints.Completed,
+	}).Info("Job wird wiederholt")
+	return job, nil
+}
+
+func (s *JobServiceImpl) MarkDownloaded(jobID string) (*domain.Job, error) {
+	return s.UpdateJob(jobID, func(job *domain.Job) error {
+		if !job.IsCompleted() {
+			return fmt.Errorf("%w: %s", domain.ErrInvalidJobStatus, job.Status)
+		}
+		job.MarkDownloaded()
+		return nil
+	})
+}
+
+// RemoveExpired prüft und löscht unter s.mu. Ein gleichzeitiges RetryJob
+// reiht den Job daher entweder vorher ein, dann ist er nicht mehr beendet
+// und bleibt erhalten, oder findet ihn danach nicht mehr.
+func (s *JobServiceImpl) RemoveExpired(jobID string, expired func(job *domain.Job) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.jobRepo.FindByID(jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !job.IsCompleted() && !job.IsFailed() && !job.IsCancelled() {
+		return false, nil
+	}
+	if !expired(job) {
+		return false, nil
+	}
+
+	if err := s.fileRepo.CleanupJob(jobID); err != nil {
+		return false, fmt.Errorf("fehler beim Bereinigen der Job-Dateien: %w", err)
+	}
+	if err := s.jobRepo.Delete(jobID); err != nil {
+		return false, fmt.Errorf("fehler beim Löschen des Jobs: %w", err)
+	}
+	return true, nil
+}
+
+func (s *JobServiceImpl) Subscribe(jobID string) (<-chan domain.JobEvent, func(), error) {
+	events := make(chan domain.JobEvent, 1)
+
+	// Der Zustand wird unter subMu gelesen: Jede spätere Änderung wird erst
+	// danach veröffentlicht und erreicht damit auch diesen Abonnenten.
+	s.subMu.Lock()
+	job, err := s.jobRepo.FindByID(jobID)
+	if err != nil {
+		s.subMu.Unlock()
+		return nil, nil, err
+	}
+	if s.subscribers[jobID] == nil {
+		s.subscribers[jobID] = make(map[chan domain.JobEvent]struct{})
+	}
+	s.subscribers[jobID][events] = struct{}{}
+	event := job.Event(domain.JobEventStatus)
+	if position, ok := s.queue.position(jobID); ok {
+		event.QueuePosition = position
+	}
+	events <- event
+	s.subMu.Unlock()
+
+	unsubscribe := func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		delete(s.subscribers[jobID], events)
+		if len(s.subscribers[jobID]) == 0 {
+			delete(s.subscribers, jobID)
+		}
+	}
+	return events, unsubscribe, nil
+}
+
+// publish schickt den aktuellen Zustand des Jobs an alle Abonnenten. Jedes
+// Ereignis enthält den vollständigen Zustand; kommt ein Abonnent nicht
+// hinterher, ersetzt es daher ein noch nicht abgeholtes Ereignis, statt den
+// Job zu blockieren. Der Zustand wird unter subMu gelesen, sodass das zuletzt
+// verschickte Ereignis immer den neuesten Stand enthält, auch wenn mehrere
+// Goroutinen gleichzeitig veröffentlichen.
+func (s *JobServiceImpl) publish(jobID string, eventType domain.JobEventType) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	if len(s.subscribers[jobID]) == 0 {
+		return
+	}
+
+	job, err := s.jobRepo.FindByID(jobID)
+	if err != nil {
+		return
+	}
+	event := job.Event(eventType)
+	if position, ok := s.queue.position(job.ID); ok {
+		event.QueuePosition = position
+	}
+	for events := range s.subscribers[job.ID] {
+		select {
+		case <-events:
+		default:
+		}
+		events <- event
+	}
+}
+
+// publishPositions meldet den wartenden Jobs ihre neue Position.
+func (s *JobServiceImpl) publishPositions(jobIDs []string) {
+	for _, jobID := range jobIDs {
+		s.publish(jobID, domain.JobEventStatus)
+	}
+}
+
+func (s *JobServiceImpl) unregister(jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, jobID)
+}
+
+func (s *JobServiceImpl) cleanupFiles(jobID string) {
+	if err := s.fileRepo.CleanupJob(jobID); err != nil {
+		s.logger.WithError(err).WithField("jobID", jobID).Warn("fehler beim Bereinigen der Job-Dateien")
+	}
+}
+
 func (s *JobServiceImpl) GetAllJobs() ([]*domain.Job, error) {
 	return s.jobRepo.FindAll()
 }